@@ -20,7 +20,7 @@ func main() {
 
 	fmt.Printf("Encoded length: %d bytes\n", len(encoded))
 
-	decoded, err := codec.Decode(encoded)
+	decoded, _, err := codec.Decode(encoded)
 	if err != nil {
 		fmt.Printf("Decode error: %v\n", err)
 		return
@@ -51,7 +51,7 @@ func main() {
 
 	fmt.Printf("Complex encoded length: %d bytes\n", len(encoded2))
 
-	decoded2, err := codec.Decode(encoded2)
+	decoded2, _, err := codec.Decode(encoded2)
 	if err != nil {
 		fmt.Printf("Decode error: %v\n", err)
 		return
@@ -70,7 +70,7 @@ func main() {
 		return
 	}
 
-	decoded3, err := codec.Decode(encoded3)
+	decoded3, _, err := codec.Decode(encoded3)
 	if err != nil {
 		fmt.Printf("Decode error: %v\n", err)
 		return
@@ -89,7 +89,7 @@ func main() {
 		return
 	}
 
-	decoded4, err := codec.Decode(encoded4)
+	decoded4, _, err := codec.Decode(encoded4)
 	if err != nil {
 		fmt.Printf("Decode error: %v\n", err)
 		return
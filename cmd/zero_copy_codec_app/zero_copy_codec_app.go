@@ -24,7 +24,7 @@ func main() {
 	fmt.Printf("Encoded length: %d bytes\n", len(encoded))
 
 	// Decode
-	decoded, err := zc.Decode(encoded)
+	decoded, _, err := zc.Decode(encoded)
 	if err != nil {
 		panic(err)
 	}
@@ -50,7 +50,7 @@ func main() {
 	}
 
 	encoded2 := zc.Encode(testData2)
-	decoded2, err := zc.Decode(encoded2)
+	decoded2, _, err := zc.Decode(encoded2)
 	if err != nil {
 		panic(err)
 	}
@@ -64,7 +64,7 @@ func main() {
 	testData3 := zc.Data{testStr, int32(100)}
 
 	encoded3 := zc.Encode(testData3)
-	decoded3, _ := zc.Decode(encoded3)
+	decoded3, _, _ := zc.Decode(encoded3)
 
 	decodedStr := decoded3[0].(string)
 	fmt.Printf("Original string address: %p\n", &testStr)
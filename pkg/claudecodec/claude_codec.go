@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"unicode/utf8"
 )
 
@@ -12,93 +13,277 @@ type Data []interface{}
 
 // Type constants for our binary format
 const (
-	TypeInt32  byte = 0x01
-	TypeString byte = 0x02
-	TypeList   byte = 0x03
+	TypeInt32   byte = 0x01
+	TypeString  byte = 0x02
+	TypeList    byte = 0x03
+	TypeInt64   byte = 0x04
+	TypeFloat64 byte = 0x05
+	TypeBool    byte = 0x06
+	TypeNull    byte = 0x07
+	TypeBytes   byte = 0x08
+	TypeMap     byte = 0x09
+
+	// TypeStringCompressed holds a string whose UTF-8 bytes were
+	// compressed with the in-tree LZ77-style block compressor (see
+	// compress.go). Decode handles it transparently alongside TypeString;
+	// Encoder.CompressionThreshold controls whether Encode produces it.
+	TypeStringCompressed byte = 0x0A
+
+	// TypeStringRef holds a uvarint index into the symbol table written at
+	// the front of the buffer as TypeSymbolTable, used in place of a plain
+	// TypeString for strings the encoder chose to intern (see intern.go).
+	TypeStringRef byte = 0x0B
+
+	// TypeSymbolTable, when present, is always the first value in a
+	// FormatFixed buffer (before the root list) and is never itself a
+	// value returned to callers: Decode consumes it into a table used to
+	// resolve TypeStringRef entries, then decodes the root value normally.
+	TypeSymbolTable byte = 0x0C
+)
+
+// UnknownTypeError is returned when Decode encounters a type byte it does
+// not recognize, so forward-compatibility layers can tell "malformed data"
+// apart from "data from a newer format version" and choose to skip it.
+type UnknownTypeError struct {
+	TypeByte byte
+}
+
+func (e *UnknownTypeError) Error() string {
+	return fmt.Sprintf("unknown type byte: 0x%02x", e.TypeByte)
+}
+
+// Format version byte written at the start of every encoded buffer so Decode
+// can tell which wire dialect produced it.
+const (
+	FormatFixed  byte = 0x00
+	FormatVarint byte = 0x01
 )
 
+// EncodeOpts selects an alternate wire dialect for Encode/AppendEncode.
+type EncodeOpts struct {
+	// Varint selects the varint wire dialect (see appendValueVarint),
+	// which trades the fixed-width string/list length fields for
+	// variable-width ones.
+	Varint bool
+}
+
 // Encode serializes Data into a compact binary format
 // Time Complexity: O(n) where n is the total number of elements across all nested structures
 // Space Complexity: O(n) for the output buffer, O(d) for recursion stack where d is max depth
 func Encode(data Data) ([]byte, error) {
-	buf := &bytes.Buffer{}
-	err := encodeValue(buf, data)
-	return buf.Bytes(), err
+	return AppendEncode(nil, data)
+}
+
+// EncodeVarint serializes data using the varint wire dialect. See
+// EncodeOpts.Varint for the tradeoffs.
+func EncodeVarint(data Data) ([]byte, error) {
+	return AppendEncodeOpts(nil, data, EncodeOpts{Varint: true})
 }
 
-// encodeValue recursively encodes a single value
-func encodeValue(buf *bytes.Buffer, value interface{}) error {
+// AppendEncode serializes data and appends the result to buf, returning the
+// extended slice. This mirrors the Append/Encode/Decode pattern Go 1.23 added
+// to encoding/binary: callers can pass in a pre-sized or pooled buffer to
+// encode a batch of messages back-to-back without each call allocating its
+// own output buffer.
+func AppendEncode(buf []byte, data Data) ([]byte, error) {
+	return AppendEncodeOpts(buf, data, EncodeOpts{})
+}
+
+// AppendEncodeOpts is AppendEncode with explicit EncodeOpts, letting callers
+// opt into the varint wire dialect.
+func AppendEncodeOpts(buf []byte, data Data, opts EncodeOpts) ([]byte, error) {
+	if opts.Varint {
+		buf = append(buf, FormatVarint)
+		return appendValueVarint(buf, data)
+	}
+	buf = append(buf, FormatFixed)
+	return appendValue(buf, data)
+}
+
+// appendValue recursively encodes a single value by appending to dst
+func appendValue(dst []byte, value interface{}) ([]byte, error) {
+	return appendValueThreshold(dst, value, 0, nil, DefaultDecoderLimits)
+}
+
+// appendValueThreshold is appendValue with an extra compressionThreshold: any
+// string whose UTF-8 byte length is at least compressionThreshold is written
+// as a compressed TypeStringCompressed payload instead of a plain TypeString
+// one. A threshold of 0 disables compression, matching appendValue.
+//
+// internIndex maps strings the caller has already written into a symbol
+// table (see intern.go) to their table index; a nil map disables interning,
+// and any string not found in a non-nil map is encoded as usual.
+//
+// limits bounds string, bytes, and list/map sizes the same way Decode's
+// DecoderLimits does. Nesting depth is not checked on encode, so a value
+// nested deeper than limits.MaxDepth encodes but fails to decode.
+func appendValueThreshold(dst []byte, value interface{}, compressionThreshold int, internIndex map[string]int, limits DecoderLimits) ([]byte, error) {
 	switch v := value.(type) {
 	case int32:
 		// Format: [TypeInt32:1][value:4]
-		buf.WriteByte(TypeInt32)
-		return binary.Write(buf, binary.LittleEndian, v)
+		dst = append(dst, TypeInt32)
+		return binary.LittleEndian.AppendUint32(dst, uint32(v)), nil
 
 	case string:
 		// Validate UTF-8
 		if !utf8.ValidString(v) {
-			return fmt.Errorf("invalid UTF-8 string")
+			return nil, fmt.Errorf("invalid UTF-8 string")
 		}
-		// Check length constraint
-		if len(v) > 1000000 {
-			return fmt.Errorf("string exceeds maximum length of 1,000,000 bytes")
+		if len(v) > limits.MaxStringBytes {
+			return nil, &LimitError{Limit: "MaxStringBytes", Got: len(v), Max: limits.MaxStringBytes}
 		}
-		// Format: [TypeString:1][length:4][utf8_bytes:length]
-		buf.WriteByte(TypeString)
-		length := uint32(len(v))
-		if err := binary.Write(buf, binary.LittleEndian, length); err != nil {
-			return err
+		if idx, ok := internIndex[v]; ok {
+			// Format: [TypeStringRef:1][index:uvarint]
+			dst = append(dst, TypeStringRef)
+			return binary.AppendUvarint(dst, uint64(idx)), nil
+		}
+		if compressionThreshold > 0 && len(v) >= compressionThreshold {
+			return appendCompressedString(dst, v), nil
 		}
-		buf.WriteString(v)
+		// Format: [TypeString:1][length:4][utf8_bytes:length]
+		dst = append(dst, TypeString)
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(v)))
+		return append(dst, v...), nil
 
 	case Data:
-		// Check length constraint
-		if len(v) > 1000 {
-			return fmt.Errorf("list exceeds maximum length of 1000")
+		if len(v) > limits.MaxListLen {
+			return nil, &LimitError{Limit: "MaxListLen", Got: len(v), Max: limits.MaxListLen}
 		}
 		// Format: [TypeList:1][count:4][element1][element2]...[elementN]
-		buf.WriteByte(TypeList)
-		count := uint32(len(v))
-		if err := binary.Write(buf, binary.LittleEndian, count); err != nil {
-			return err
-		}
+		dst = append(dst, TypeList)
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(v)))
 		for _, item := range v {
-			if err := encodeValue(buf, item); err != nil {
-				return err
+			var err error
+			dst, err = appendValueThreshold(dst, item, compressionThreshold, internIndex, limits)
+			if err != nil {
+				return nil, err
 			}
 		}
+		return dst, nil
 
 	case []interface{}:
 		// Handle slice converted to Data
-		return encodeValue(buf, Data(v))
+		return appendValueThreshold(dst, Data(v), compressionThreshold, internIndex, limits)
+
+	case int64:
+		// Format: [TypeInt64:1][value:8]
+		dst = append(dst, TypeInt64)
+		return binary.LittleEndian.AppendUint64(dst, uint64(v)), nil
+
+	case float64:
+		// Format: [TypeFloat64:1][bits:8]
+		dst = append(dst, TypeFloat64)
+		return binary.LittleEndian.AppendUint64(dst, math.Float64bits(v)), nil
+
+	case bool:
+		// Format: [TypeBool:1][value:1]
+		dst = append(dst, TypeBool)
+		if v {
+			return append(dst, 1), nil
+		}
+		return append(dst, 0), nil
+
+	case nil:
+		// Format: [TypeNull:1]
+		return append(dst, TypeNull), nil
+
+	case []byte:
+		if len(v) > limits.MaxStringBytes {
+			return nil, &LimitError{Limit: "MaxStringBytes", Got: len(v), Max: limits.MaxStringBytes}
+		}
+		// Format: [TypeBytes:1][length:4][bytes:length]
+		dst = append(dst, TypeBytes)
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(v)))
+		return append(dst, v...), nil
+
+	case map[string]interface{}:
+		if len(v) > limits.MaxListLen {
+			return nil, &LimitError{Limit: "MaxListLen", Got: len(v), Max: limits.MaxListLen}
+		}
+		// Format: [TypeMap:1][count:4]{[key:string][value:any]}*
+		dst = append(dst, TypeMap)
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(v)))
+		for key, val := range v {
+			var err error
+			dst, err = appendValueThreshold(dst, key, compressionThreshold, internIndex, limits)
+			if err != nil {
+				return nil, err
+			}
+			dst, err = appendValueThreshold(dst, val, compressionThreshold, internIndex, limits)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
 
 	default:
-		return fmt.Errorf("unsupported type: %T", v)
+		return nil, fmt.Errorf("unsupported type: %T", v)
 	}
-
-	return nil
 }
 
-// Decode deserializes binary data back into Data structure
+// Decode deserializes a value from the front of buf, returning the decoded
+// Data along with the number of bytes consumed so callers can continue
+// decoding subsequent messages packed into the same buffer. The wire dialect
+// (fixed-width or varint) is auto-detected from the leading format version
+// byte written by Encode/AppendEncode. Decode enforces DefaultDecoderLimits;
+// use a Decoder for custom limits.
 // Time Complexity: O(n) where n is the total number of elements
 // Space Complexity: O(n) for the result + O(d) for recursion stack where d is max depth
-func Decode(data []byte) (Data, error) {
-	buf := bytes.NewReader(data)
-	value, err := decodeValue(buf)
-	if err != nil {
-		return nil, err
-	}
+func Decode(buf []byte) (Data, int, error) {
+	return NewDecoder().Decode(buf)
+}
 
-	// The root must be a list
-	if result, ok := value.(Data); ok {
-		return result, nil
+// decodeWithLimits is Decode's implementation, parameterized on limits so
+// Decoder.Decode can pass a caller-supplied DecoderLimits.
+func decodeWithLimits(buf []byte, limits DecoderLimits) (Data, int, error) {
+	if len(buf) < 1 {
+		return nil, 0, fmt.Errorf("buffer too short: missing format version byte")
 	}
 
-	return nil, fmt.Errorf("root value must be a list")
+	switch buf[0] {
+	case FormatFixed:
+		r := bytes.NewReader(buf[1:])
+		table, err := maybeDecodeSymbolTable(r, limits)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, err := decodeValue(r, table, limits, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		result, ok := value.(Data)
+		if !ok {
+			return nil, 0, fmt.Errorf("root value must be a list")
+		}
+		return result, 1 + (len(buf[1:]) - r.Len()), nil
+
+	case FormatVarint:
+		value, n, err := decodeValueVarint(buf[1:], limits)
+		if err != nil {
+			return nil, 0, err
+		}
+		result, ok := value.(Data)
+		if !ok {
+			return nil, 0, fmt.Errorf("root value must be a list")
+		}
+		return result, 1 + n, nil
+
+	default:
+		return nil, 0, fmt.Errorf("unknown format version: 0x%02x", buf[0])
+	}
 }
 
-// decodeValue recursively decodes a single value
-func decodeValue(buf *bytes.Reader) (interface{}, error) {
+// decodeValue recursively decodes a single value. table resolves
+// TypeStringRef entries written by an Encoder with Intern enabled (see
+// intern.go); it is nil when the buffer carries no symbol table. depth is
+// the nesting depth of this call (the root value is depth 1), checked
+// against limits.MaxDepth to bound the recursion before it reaches the
+// Go stack.
+func decodeValue(buf *bytes.Reader, table []string, limits DecoderLimits, depth int) (interface{}, error) {
+	if depth > limits.MaxDepth {
+		return nil, &LimitError{Limit: "MaxDepth", Got: depth, Max: limits.MaxDepth}
+	}
+
 	typeByte, err := buf.ReadByte()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read type byte: %v", err)
@@ -120,8 +305,8 @@ func decodeValue(buf *bytes.Reader) (interface{}, error) {
 			return nil, fmt.Errorf("failed to read string length: %v", err)
 		}
 
-		if length > 1000000 {
-			return nil, fmt.Errorf("string length %d exceeds maximum of 1,000,000", length)
+		if int(length) > limits.MaxStringBytes {
+			return nil, &LimitError{Limit: "MaxStringBytes", Got: int(length), Max: limits.MaxStringBytes}
 		}
 
 		stringBytes := make([]byte, length)
@@ -144,13 +329,13 @@ func decodeValue(buf *bytes.Reader) (interface{}, error) {
 			return nil, fmt.Errorf("failed to read list count: %v", err)
 		}
 
-		if count > 1000 {
-			return nil, fmt.Errorf("list count %d exceeds maximum of 1000", count)
+		if int(count) > limits.MaxListLen {
+			return nil, &LimitError{Limit: "MaxListLen", Got: int(count), Max: limits.MaxListLen}
 		}
 
 		result := make(Data, count)
 		for i := uint32(0); i < count; i++ {
-			value, err := decodeValue(buf)
+			value, err := decodeValue(buf, table, limits, depth+1)
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode list element %d: %v", i, err)
 			}
@@ -159,8 +344,97 @@ func decodeValue(buf *bytes.Reader) (interface{}, error) {
 
 		return result, nil
 
+	case TypeInt64:
+		var value uint64
+		err := binary.Read(buf, binary.LittleEndian, &value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read int64: %v", err)
+		}
+		return int64(value), nil
+
+	case TypeFloat64:
+		var bits uint64
+		err := binary.Read(buf, binary.LittleEndian, &bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read float64: %v", err)
+		}
+		return math.Float64frombits(bits), nil
+
+	case TypeBool:
+		b, err := buf.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bool: %v", err)
+		}
+		return b != 0, nil
+
+	case TypeNull:
+		return nil, nil
+
+	case TypeBytes:
+		var length uint32
+		err := binary.Read(buf, binary.LittleEndian, &length)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bytes length: %v", err)
+		}
+
+		if int(length) > limits.MaxStringBytes {
+			return nil, &LimitError{Limit: "MaxStringBytes", Got: int(length), Max: limits.MaxStringBytes}
+		}
+
+		raw := make([]byte, length)
+		n, err := buf.Read(raw)
+		if err != nil || uint32(n) != length {
+			return nil, fmt.Errorf("failed to read bytes data: %v", err)
+		}
+
+		return raw, nil
+
+	case TypeMap:
+		var count uint32
+		err := binary.Read(buf, binary.LittleEndian, &count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read map count: %v", err)
+		}
+
+		if int(count) > limits.MaxListLen {
+			return nil, &LimitError{Limit: "MaxListLen", Got: int(count), Max: limits.MaxListLen}
+		}
+
+		result := make(map[string]interface{}, count)
+		for i := uint32(0); i < count; i++ {
+			keyValue, err := decodeValue(buf, table, limits, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode map key %d: %v", i, err)
+			}
+			key, ok := keyValue.(string)
+			if !ok {
+				return nil, fmt.Errorf("map key %d must be a string", i)
+			}
+
+			value, err := decodeValue(buf, table, limits, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode map value %d: %v", i, err)
+			}
+			result[key] = value
+		}
+
+		return result, nil
+
+	case TypeStringCompressed:
+		return decodeCompressedString(buf, limits)
+
+	case TypeStringRef:
+		idx, err := binary.ReadUvarint(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read string ref index: %v", err)
+		}
+		if idx >= uint64(len(table)) {
+			return nil, fmt.Errorf("string ref index %d out of range (table has %d entries)", idx, len(table))
+		}
+		return table[idx], nil
+
 	default:
-		return nil, fmt.Errorf("unknown type byte: 0x%02x", typeByte)
+		return nil, &UnknownTypeError{TypeByte: typeByte}
 	}
 }
 
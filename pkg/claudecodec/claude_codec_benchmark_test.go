@@ -157,7 +157,7 @@ func BenchmarkDecodeSimpleSmall(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := Decode(encoded)
+		_, _, err := Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -170,7 +170,7 @@ func BenchmarkDecodeSimpleMedium(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := Decode(encoded)
+		_, _, err := Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -183,7 +183,7 @@ func BenchmarkDecodeSimpleLarge(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := Decode(encoded)
+		_, _, err := Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -196,7 +196,7 @@ func BenchmarkDecodeNestedShallow(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := Decode(encoded)
+		_, _, err := Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -209,7 +209,7 @@ func BenchmarkDecodeNestedDeep(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := Decode(encoded)
+		_, _, err := Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -222,7 +222,7 @@ func BenchmarkDecodeLargeStrings(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := Decode(encoded)
+		_, _, err := Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -238,7 +238,7 @@ func BenchmarkRoundTripSmall(b *testing.B) {
 		if err != nil {
 			b.Fatal(err)
 		}
-		_, err = Decode(encoded)
+		_, _, err = Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -254,7 +254,7 @@ func BenchmarkRoundTripMedium(b *testing.B) {
 		if err != nil {
 			b.Fatal(err)
 		}
-		_, err = Decode(encoded)
+		_, _, err = Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -270,7 +270,7 @@ func BenchmarkRoundTripLarge(b *testing.B) {
 		if err != nil {
 			b.Fatal(err)
 		}
-		_, err = Decode(encoded)
+		_, _, err = Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -300,7 +300,7 @@ func BenchmarkDecodeMemoryAlloc(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := Decode(encoded)
+		_, _, err := Decode(encoded)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -0,0 +1,273 @@
+package claudecodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------------------------
+// Optional per-string compression
+// ----------------------------------------------------------------------------
+// Encoder lets callers compress large string payloads with an in-tree
+// Snappy-style LZ77 block compressor, keeping the module dependency-free.
+// Strings at or above CompressionThreshold are written as
+// TypeStringCompressed: [uncompressed_len:uvarint][compressed_len:uvarint]
+// [compressed_bytes]. Decode handles this tag transparently regardless of
+// which encoder produced it, so any decoder understands both old,
+// uncompressed buffers and new, compressed ones.
+// ----------------------------------------------------------------------------
+
+const defaultCompressionThreshold = 1024 // 1 KiB
+
+// Encoder wraps the package-level encode functions with configurable
+// options. The zero value never compresses or interns; use NewEncoder for
+// the default CompressionThreshold.
+type Encoder struct {
+	// CompressionThreshold is the minimum UTF-8 byte length a string must
+	// reach before Encode compresses it into a TypeStringCompressed
+	// payload. Zero disables compression.
+	CompressionThreshold int
+
+	// Intern enables the symbol table described in intern.go: strings
+	// that repeat often enough to be worth deduplicating are written once
+	// into a table at the front of the buffer and referenced by index
+	// everywhere else.
+	Intern bool
+
+	// Limits bounds string, bytes, and list/map sizes (see limits.go). The
+	// zero value behaves like DefaultDecoderLimits.
+	Limits DecoderLimits
+}
+
+// NewEncoder creates an Encoder with the default CompressionThreshold (1 KiB)
+// and DefaultDecoderLimits.
+func NewEncoder() *Encoder {
+	return &Encoder{
+		CompressionThreshold: defaultCompressionThreshold,
+		Limits:               DefaultDecoderLimits,
+	}
+}
+
+// Encode serializes data, compressing strings at or above CompressionThreshold.
+func (e *Encoder) Encode(data Data) ([]byte, error) {
+	return e.AppendEncode(nil, data)
+}
+
+// AppendEncode is Encode that appends to buf, mirroring the package-level AppendEncode.
+func (e *Encoder) AppendEncode(buf []byte, data Data) ([]byte, error) {
+	buf = append(buf, FormatFixed)
+
+	var internIndex map[string]int
+	if e.Intern {
+		var table []string
+		table, internIndex = buildInternTable(data)
+		if len(table) > 0 {
+			var err error
+			buf, err = appendSymbolTable(buf, table)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return appendValueThreshold(buf, data, e.CompressionThreshold, internIndex, resolveLimits(e.Limits))
+}
+
+// appendCompressedString compresses s and appends it to dst as a
+// TypeStringCompressed payload. If compression does not make s smaller, s is
+// appended as a plain TypeString instead.
+func appendCompressedString(dst []byte, s string) []byte {
+	compressed := compressBlock([]byte(s))
+	if len(compressed) >= len(s) {
+		dst = append(dst, TypeString)
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(s)))
+		return append(dst, s...)
+	}
+	dst = append(dst, TypeStringCompressed)
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	dst = binary.AppendUvarint(dst, uint64(len(compressed)))
+	return append(dst, compressed...)
+}
+
+// decodeCompressedString reads a TypeStringCompressed payload (the type byte
+// itself already consumed by the caller) and returns the decompressed string.
+// limits bounds the decompressed length exactly like decodeValue does for a
+// plain TypeString.
+func decodeCompressedString(buf *bytes.Reader, limits DecoderLimits) (interface{}, error) {
+	uncompressedLen, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compressed string uncompressed length: %v", err)
+	}
+	if uncompressedLen > uint64(limits.MaxStringBytes) {
+		return nil, &LimitError{Limit: "MaxStringBytes", Got: int(uncompressedLen), Max: limits.MaxStringBytes}
+	}
+
+	compressedLen, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compressed string compressed length: %v", err)
+	}
+	// Bound compressedLen before allocating: it comes straight off the wire,
+	// and appendCompressedString only writes a compressed payload smaller
+	// than the uncompressed string (already checked against
+	// limits.MaxStringBytes), so it also fits within that cap.
+	if compressedLen > uint64(limits.MaxStringBytes) {
+		return nil, &LimitError{Limit: "MaxStringBytes", Got: int(compressedLen), Max: limits.MaxStringBytes}
+	}
+
+	compressed := make([]byte, compressedLen)
+	n, err := buf.Read(compressed)
+	if err != nil || uint64(n) != compressedLen {
+		return nil, fmt.Errorf("failed to read compressed string data: %v", err)
+	}
+
+	raw, err := decompressBlock(compressed, int(uncompressedLen))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress string: %v", err)
+	}
+
+	str := string(raw)
+	if !utf8.ValidString(str) {
+		return nil, fmt.Errorf("invalid UTF-8 string")
+	}
+	return str, nil
+}
+
+// ----------------------------------------------------------------------------
+// In-tree LZ77-style block compressor
+// ----------------------------------------------------------------------------
+// compressBlock/decompressBlock implement a single-pass matcher over a
+// 16 KiB lookback window, in the spirit of Snappy's block format but much
+// simpler: the output is a sequence of opcodes, each either a literal run
+// ([opLiteral][len:uvarint][bytes]) or a back-reference
+// ([opMatch][offset:uvarint][length:uvarint]).
+// ----------------------------------------------------------------------------
+
+const (
+	compressWindowSize = 16 * 1024
+	compressMinMatch   = 4
+	compressHashBits   = 15
+)
+
+const (
+	opLiteral byte = 0x00
+	opMatch   byte = 0x01
+)
+
+// compressBlock compresses src, returning the compressed opcode stream.
+func compressBlock(src []byte) []byte {
+	var dst []byte
+	hashTable := make(map[uint32]int)
+
+	litStart := 0
+	i := 0
+
+	flushLiteral := func(end int) {
+		if end <= litStart {
+			return
+		}
+		dst = append(dst, opLiteral)
+		dst = binary.AppendUvarint(dst, uint64(end-litStart))
+		dst = append(dst, src[litStart:end]...)
+	}
+
+	for i+compressMinMatch <= len(src) {
+		h := hashFour(src[i:])
+		candidate, seen := hashTable[h]
+		hashTable[h] = i
+
+		if seen && i-candidate <= compressWindowSize &&
+			bytes.Equal(src[candidate:candidate+compressMinMatch], src[i:i+compressMinMatch]) {
+			matchLen := compressMinMatch
+			for i+matchLen < len(src) && src[candidate+matchLen] == src[i+matchLen] {
+				matchLen++
+			}
+
+			flushLiteral(i)
+			dst = append(dst, opMatch)
+			dst = binary.AppendUvarint(dst, uint64(i-candidate))
+			dst = binary.AppendUvarint(dst, uint64(matchLen))
+
+			i += matchLen
+			litStart = i
+			continue
+		}
+
+		i++
+	}
+
+	flushLiteral(len(src))
+	return dst
+}
+
+// hashFour hashes the first 4 bytes of b into a compressHashBits-wide bucket.
+func hashFour(b []byte) uint32 {
+	v := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	return (v * 2654435761) >> (32 - compressHashBits)
+}
+
+// decompressBlock reverses compressBlock, checking the result matches
+// uncompressedLen exactly. Every literal run and match length is bounded
+// against the uncompressed bytes remaining before it is copied, so a
+// forged opcode claiming an oversized run can't grow dst past
+// uncompressedLen before the mismatch is ever detected.
+func decompressBlock(src []byte, uncompressedLen int) ([]byte, error) {
+	dst := make([]byte, 0, uncompressedLen)
+	i := 0
+
+	for i < len(src) {
+		op := src[i]
+		i++
+
+		switch op {
+		case opLiteral:
+			n, k := binary.Uvarint(src[i:])
+			if k <= 0 {
+				return nil, fmt.Errorf("invalid literal run length")
+			}
+			i += k
+			if n > uint64(uncompressedLen-len(dst)) {
+				return nil, fmt.Errorf("literal run of %d bytes overruns uncompressed length %d", n, uncompressedLen)
+			}
+			if i+int(n) > len(src) {
+				return nil, fmt.Errorf("truncated literal run")
+			}
+			dst = append(dst, src[i:i+int(n)]...)
+			i += int(n)
+
+		case opMatch:
+			offset, k := binary.Uvarint(src[i:])
+			if k <= 0 {
+				return nil, fmt.Errorf("invalid match offset")
+			}
+			i += k
+
+			length, k2 := binary.Uvarint(src[i:])
+			if k2 <= 0 {
+				return nil, fmt.Errorf("invalid match length")
+			}
+			i += k2
+			if length > uint64(uncompressedLen-len(dst)) {
+				return nil, fmt.Errorf("match of %d bytes overruns uncompressed length %d", length, uncompressedLen)
+			}
+
+			start := len(dst) - int(offset)
+			if offset == 0 || start < 0 {
+				return nil, fmt.Errorf("match offset out of range")
+			}
+			for j := 0; j < int(length); j++ {
+				dst = append(dst, dst[start+j])
+			}
+
+		default:
+			return nil, fmt.Errorf("invalid compressed block opcode: 0x%02x", op)
+		}
+	}
+
+	if len(dst) != uncompressedLen {
+		return nil, fmt.Errorf("decompressed length mismatch: want %d, got %d", uncompressedLen, len(dst))
+	}
+
+	return dst, nil
+}
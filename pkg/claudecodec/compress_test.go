@@ -0,0 +1,117 @@
+package claudecodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestEncoderCompressesLargeStrings(t *testing.T) {
+	repeated := ""
+	for i := 0; i < 200; i++ {
+		repeated += "the quick brown fox jumps over the lazy dog "
+	}
+	data := NewData(repeated, int32(1))
+
+	enc := NewEncoder()
+	encoded, err := enc.Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	plain, err := Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	if len(encoded) >= len(plain) {
+		t.Errorf("expected compressed encoding (%d bytes) to be smaller than uncompressed (%d bytes)", len(encoded), len(plain))
+	}
+
+	decoded, _, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if decoded[0].(string) != repeated {
+		t.Error("compressed string did not round-trip")
+	}
+}
+
+func TestEncoderBelowThresholdStaysUncompressed(t *testing.T) {
+	enc := NewEncoder()
+	data := NewData("short")
+
+	encoded, err := enc.Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	decoded, n, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if n != len(encoded) {
+		t.Errorf("expected to consume the whole buffer, consumed %d of %d", n, len(encoded))
+	}
+	if decoded[0].(string) != "short" {
+		t.Error("short string round trip failed")
+	}
+
+	// A short string below the default threshold should still use the
+	// plain TypeString tag, not TypeStringCompressed. Layout:
+	// [FormatFixed][TypeList][count:4][TypeString]...
+	if encoded[1] != TypeList || encoded[6] != TypeString {
+		t.Errorf("expected uncompressed TypeString tag, got wire bytes %v", encoded[:7])
+	}
+}
+
+func TestCompressBlockRoundTrip(t *testing.T) {
+	cases := []string{
+		"",
+		"a",
+		"abcabcabcabcabcabcabc",
+		"the quick brown fox jumps over the lazy dog the quick brown fox",
+	}
+
+	for _, c := range cases {
+		compressed := compressBlock([]byte(c))
+		decompressed, err := decompressBlock(compressed, len(c))
+		if err != nil {
+			t.Fatalf("decompressBlock(%q): %v", c, err)
+		}
+		if string(decompressed) != c {
+			t.Errorf("round trip mismatch: want %q, got %q", c, decompressed)
+		}
+	}
+}
+
+func TestDecompressBlockRejectsMatchLengthOverrunningUncompressedLen(t *testing.T) {
+	// A forged opMatch claiming a back-reference far longer than the
+	// declared uncompressed length must be rejected immediately rather
+	// than growing dst out to the claimed length first.
+	var block []byte
+	block = append(block, opMatch)
+	block = binary.AppendUvarint(block, 1)     // offset
+	block = binary.AppendUvarint(block, 1<<33) // length: wildly oversized
+
+	_, err := decompressBlock(block, 10)
+	if err == nil || !strings.Contains(err.Error(), "overruns uncompressed length") {
+		t.Fatalf("expected an overrun error, got %v", err)
+	}
+}
+
+func TestDecodeCompressedStringRejectsOversizedCompressedLen(t *testing.T) {
+	// A forged TypeStringCompressed payload claiming a tiny uncompressed
+	// length but a huge compressed length must be rejected before it
+	// allocates a buffer of that size.
+	var payload []byte
+	payload = binary.AppendUvarint(payload, 10)    // uncompressedLen
+	payload = binary.AppendUvarint(payload, 1<<40) // compressedLen: wildly oversized
+
+	_, err := decodeCompressedString(bytes.NewReader(payload), DefaultDecoderLimits)
+	limitErr, ok := err.(*LimitError)
+	if !ok || limitErr.Limit != "MaxStringBytes" {
+		t.Fatalf("expected a MaxStringBytes LimitError, got %v", err)
+	}
+}
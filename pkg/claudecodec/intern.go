@@ -0,0 +1,149 @@
+package claudecodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------------------------
+// Optional string interning
+// ----------------------------------------------------------------------------
+// Encoder.Intern lets callers deduplicate strings that repeat across a
+// payload (typical for map keys in a list of similarly-shaped records) into
+// a single symbol table written once at the front of the buffer, right
+// after the format version byte:
+//
+//	[TypeSymbolTable][count:uvarint]{[len:uvarint][utf8_bytes]}*
+//
+// Every subsequent occurrence of an interned string is written as
+// TypeStringRef + a uvarint index into that table instead of a full
+// TypeString payload. Decode resolves refs against the table it just read,
+// so old, non-interned buffers (which have no TypeSymbolTable at all)
+// decode exactly as before.
+// ----------------------------------------------------------------------------
+
+const maxSymbolTableLen = 1000
+
+// buildInternTable scans data for strings worth interning: each must repeat
+// at least twice and only earns its spot once referencing it repeatedly
+// costs less than writing it out in full each time. The table entry itself
+// costs len(s) bytes plus a couple of bytes of uvarint bookkeeping, so
+// requiring len(s)*count to clear that overhead keeps marginal or
+// single-use strings out of the table. It returns the qualifying strings in
+// a deterministic order (so repeated encodes of the same Data produce
+// identical output) along with a map from string to table index.
+func buildInternTable(data Data) (table []string, index map[string]int) {
+	counts := make(map[string]int)
+	countStrings(data, counts)
+
+	for s, count := range counts {
+		overhead := len(s) + 2
+		if count >= 2 && len(s)*count > overhead {
+			table = append(table, s)
+		}
+	}
+	sort.Strings(table)
+
+	index = make(map[string]int, len(table))
+	for i, s := range table {
+		index[s] = i
+	}
+	return table, index
+}
+
+// appendSymbolTable writes table to dst as a TypeSymbolTable value. Callers
+// should only call this when table is non-empty; an empty table would add
+// two bytes to every encode for no benefit.
+func appendSymbolTable(dst []byte, table []string) ([]byte, error) {
+	if len(table) > maxSymbolTableLen {
+		return nil, fmt.Errorf("symbol table of %d entries exceeds maximum of %d", len(table), maxSymbolTableLen)
+	}
+
+	dst = append(dst, TypeSymbolTable)
+	dst = binary.AppendUvarint(dst, uint64(len(table)))
+	for _, s := range table {
+		dst = binary.AppendUvarint(dst, uint64(len(s)))
+		dst = append(dst, s...)
+	}
+
+	return dst, nil
+}
+
+// countStrings walks value, tallying every string it finds (including map
+// keys) into counts.
+func countStrings(value interface{}, counts map[string]int) {
+	switch v := value.(type) {
+	case string:
+		counts[v]++
+
+	case Data:
+		for _, item := range v {
+			countStrings(item, counts)
+		}
+
+	case []interface{}:
+		countStrings(Data(v), counts)
+
+	case map[string]interface{}:
+		for key, val := range v {
+			counts[key]++
+			countStrings(val, counts)
+		}
+	}
+}
+
+// maybeDecodeSymbolTable reads a TypeSymbolTable off the front of buf, if
+// present, and returns the decoded table. Buffers with no symbol table
+// (the common case) leave buf untouched and return a nil table. limits
+// bounds the table's entry count (against MaxListLen, the same limit a
+// TypeList or TypeMap element count is checked against) and each entry's
+// byte length (against MaxStringBytes), exactly like decodeValue does for
+// ordinary strings and lists.
+func maybeDecodeSymbolTable(buf *bytes.Reader, limits DecoderLimits) ([]string, error) {
+	tag, err := buf.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read type byte: %v", err)
+	}
+	if tag != TypeSymbolTable {
+		if err := buf.UnreadByte(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	count, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbol table count: %v", err)
+	}
+	if count > uint64(limits.MaxListLen) {
+		return nil, &LimitError{Limit: "MaxListLen", Got: int(count), Max: limits.MaxListLen}
+	}
+
+	table := make([]string, count)
+	for i := uint64(0); i < count; i++ {
+		length, err := binary.ReadUvarint(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read symbol %d length: %v", i, err)
+		}
+		if length > uint64(limits.MaxStringBytes) {
+			return nil, &LimitError{Limit: "MaxStringBytes", Got: int(length), Max: limits.MaxStringBytes}
+		}
+
+		raw := make([]byte, length)
+		n, err := buf.Read(raw)
+		if err != nil || uint64(n) != length {
+			return nil, fmt.Errorf("failed to read symbol %d data: %v", i, err)
+		}
+
+		str := string(raw)
+		if !utf8.ValidString(str) {
+			return nil, fmt.Errorf("invalid UTF-8 symbol at index %d", i)
+		}
+		table[i] = str
+	}
+
+	return table, nil
+}
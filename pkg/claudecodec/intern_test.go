@@ -0,0 +1,81 @@
+package claudecodec
+
+import "testing"
+
+func TestInternDeduplicatesRepeatedStrings(t *testing.T) {
+	data := NewData(
+		map[string]interface{}{"name": "alice", "role": "admin"},
+		map[string]interface{}{"name": "bob", "role": "admin"},
+		map[string]interface{}{"name": "carol", "role": "admin"},
+	)
+
+	enc := &Encoder{Intern: true}
+	encoded, err := enc.Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	plain, err := Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	if len(encoded) >= len(plain) {
+		t.Errorf("expected interned encoding (%d bytes) to be smaller than uncompressed (%d bytes)", len(encoded), len(plain))
+	}
+
+	decoded, n, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if n != len(encoded) {
+		t.Errorf("expected to consume the whole buffer, consumed %d of %d", n, len(encoded))
+	}
+
+	if len(decoded) != len(data) {
+		t.Fatalf("expected %d elements, got %d", len(data), len(decoded))
+	}
+	for i, item := range decoded {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			t.Fatalf("element %d: expected map, got %T", i, item)
+		}
+		want := data[i].(map[string]interface{})
+		if m["name"] != want["name"] || m["role"] != want["role"] {
+			t.Errorf("element %d did not round-trip: want %v, got %v", i, want, m)
+		}
+	}
+}
+
+func TestInternBackwardCompatibleWithPlainBuffers(t *testing.T) {
+	data := NewData("short", int32(1))
+
+	encoded, err := Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	decoded, _, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode of non-interned buffer failed: %v", err)
+	}
+	if decoded[0].(string) != "short" {
+		t.Error("plain buffer without a symbol table did not round-trip")
+	}
+}
+
+func TestInternLeavesSmallOrUniqueStringsInline(t *testing.T) {
+	data := NewData("a", "b", "c")
+
+	enc := &Encoder{Intern: true}
+	encoded, err := enc.Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	// None of these strings repeat, so no symbol table should be written:
+	// [FormatFixed][TypeList]...
+	if encoded[1] != TypeList {
+		t.Errorf("expected no symbol table for non-repeating strings, got wire bytes %v", encoded[:2])
+	}
+}
@@ -0,0 +1,95 @@
+package claudecodec
+
+import "fmt"
+
+// ----------------------------------------------------------------------------
+// Configurable size limits
+// ----------------------------------------------------------------------------
+// DecoderLimits replaces the format's hard-coded string/list size caps with
+// configurable ones, and adds a nesting-depth cap that didn't exist before:
+// without it, a hostile buffer containing a TypeList nested a million deep
+// can blow the Go stack during Decode's recursive descent. Decode (with no
+// explicit Decoder) uses DefaultDecoderLimits, whose string and list caps
+// match the ones this package has always enforced.
+// ----------------------------------------------------------------------------
+
+// DecoderLimits bounds the resources a single Decode call may consume. A
+// zero field falls back to the matching DefaultDecoderLimits value, except
+// MaxTotalBytes, where zero means "no limit".
+type DecoderLimits struct {
+	// MaxStringBytes bounds the UTF-8 byte length of a single TypeString
+	// or TypeBytes value.
+	MaxStringBytes int
+	// MaxListLen bounds the element count of a single TypeList or TypeMap
+	// value.
+	MaxListLen int
+	// MaxDepth bounds how deeply TypeList/TypeMap values may nest.
+	MaxDepth int
+	// MaxTotalBytes bounds the size of the buffer passed to Decode. Zero
+	// means no limit.
+	MaxTotalBytes int
+}
+
+// DefaultDecoderLimits keeps the string and list caps this package enforced
+// before DecoderLimits existed. MaxDepth is new: Decode now rejects values
+// nested more than 1000 levels deep.
+var DefaultDecoderLimits = DecoderLimits{
+	MaxStringBytes: 1000000,
+	MaxListLen:     1000,
+	MaxDepth:       1000,
+	MaxTotalBytes:  0,
+}
+
+// resolveLimits fills zero fields of l with the matching DefaultDecoderLimits
+// value, so a zero-value DecoderLimits (e.g. from an Encoder or Decoder
+// constructed as a bare struct literal) behaves like DefaultDecoderLimits
+// rather than "everything forbidden".
+func resolveLimits(l DecoderLimits) DecoderLimits {
+	if l.MaxStringBytes == 0 {
+		l.MaxStringBytes = DefaultDecoderLimits.MaxStringBytes
+	}
+	if l.MaxListLen == 0 {
+		l.MaxListLen = DefaultDecoderLimits.MaxListLen
+	}
+	if l.MaxDepth == 0 {
+		l.MaxDepth = DefaultDecoderLimits.MaxDepth
+	}
+	return l
+}
+
+// LimitError is returned when a Decode or Encode call exceeds a
+// DecoderLimits field, naming which one so callers can tell a hostile or
+// malformed payload apart from a plain format error.
+type LimitError struct {
+	Limit string // the DecoderLimits field name that was exceeded
+	Got   int
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s exceeded: got %d, max %d", e.Limit, e.Got, e.Max)
+}
+
+// Decoder decodes buffers produced by Encode/AppendEncode while enforcing
+// Limits. The zero value behaves like DefaultDecoderLimits; use NewDecoder
+// for that explicitly, or set Limits directly for custom caps.
+type Decoder struct {
+	Limits DecoderLimits
+}
+
+// NewDecoder creates a Decoder with DefaultDecoderLimits.
+func NewDecoder() *Decoder {
+	return &Decoder{Limits: DefaultDecoderLimits}
+}
+
+// Decode deserializes a value from the front of buf, enforcing d.Limits.
+// See the package-level Decode for the return value semantics.
+func (d *Decoder) Decode(buf []byte) (Data, int, error) {
+	limits := resolveLimits(d.Limits)
+
+	if limits.MaxTotalBytes > 0 && len(buf) > limits.MaxTotalBytes {
+		return nil, 0, &LimitError{Limit: "MaxTotalBytes", Got: len(buf), Max: limits.MaxTotalBytes}
+	}
+
+	return decodeWithLimits(buf, limits)
+}
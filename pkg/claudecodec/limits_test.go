@@ -0,0 +1,120 @@
+package claudecodec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecoderDefaultLimitsMatchPackageDefaults(t *testing.T) {
+	data := NewData("hello", int32(42))
+
+	encoded, err := Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	decoded, n, err := NewDecoder().Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if n != len(encoded) {
+		t.Errorf("expected to consume the whole buffer, consumed %d of %d", n, len(encoded))
+	}
+	if decoded[0].(string) != "hello" {
+		t.Error("string did not round-trip")
+	}
+}
+
+func TestDecoderRejectsOversizedString(t *testing.T) {
+	data := NewData("this string is over the custom limit")
+
+	encoded, err := Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	dec := &Decoder{Limits: DecoderLimits{MaxStringBytes: 4, MaxListLen: 10, MaxDepth: 10}}
+	_, _, err = dec.Decode(encoded)
+	if err == nil || !strings.Contains(err.Error(), "MaxStringBytes exceeded") {
+		t.Fatalf("expected a MaxStringBytes LimitError, got %v", err)
+	}
+}
+
+func TestDecoderRejectsExcessiveDepth(t *testing.T) {
+	nested := NewData(NewData(NewData("deep")))
+
+	encoded, err := Encode(nested)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	dec := &Decoder{Limits: DecoderLimits{MaxDepth: 2}}
+	_, _, err = dec.Decode(encoded)
+	if err == nil || !strings.Contains(err.Error(), "MaxDepth exceeded") {
+		t.Fatalf("expected a MaxDepth LimitError, got %v", err)
+	}
+}
+
+func TestDecoderRejectsExcessiveDepthInVarintDialect(t *testing.T) {
+	nested := NewData(NewData(NewData("deep")))
+
+	encoded, err := EncodeVarint(nested)
+	if err != nil {
+		t.Fatalf("EncodeVarint: %v", err)
+	}
+
+	dec := &Decoder{Limits: DecoderLimits{MaxDepth: 2}}
+	_, _, err = dec.Decode(encoded)
+	if err == nil || !strings.Contains(err.Error(), "MaxDepth exceeded") {
+		t.Fatalf("expected a MaxDepth LimitError, got %v", err)
+	}
+}
+
+func TestDecoderRejectsOversizedCompressedString(t *testing.T) {
+	enc := &Encoder{CompressionThreshold: 10, Limits: DecoderLimits{MaxStringBytes: 1000000, MaxListLen: 1000, MaxDepth: 1000}}
+	encoded, err := enc.Encode(NewData(strings.Repeat("this string is over the custom limit ", 3)))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	dec := &Decoder{Limits: DecoderLimits{MaxStringBytes: 10, MaxListLen: 1000, MaxDepth: 1000}}
+	_, _, err = dec.Decode(encoded)
+	if err == nil || !strings.Contains(err.Error(), "MaxStringBytes exceeded") {
+		t.Fatalf("expected a MaxStringBytes LimitError, got %v", err)
+	}
+}
+
+func TestDecoderRejectsOversizedSymbolTableUnderCustomLimits(t *testing.T) {
+	data := NewData("repeat", "repeat", "repeat")
+
+	enc := &Encoder{Intern: true, Limits: DecoderLimits{MaxStringBytes: 1000000, MaxListLen: 1000, MaxDepth: 1000}}
+	encoded, err := enc.Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	dec := &Decoder{Limits: DecoderLimits{MaxStringBytes: 1000000, MaxListLen: 1000, MaxDepth: 1000}}
+	if _, _, err := dec.Decode(encoded); err != nil {
+		t.Fatalf("Decode with ample limits: %v", err)
+	}
+
+	dec = &Decoder{Limits: DecoderLimits{MaxStringBytes: 3, MaxListLen: 1000, MaxDepth: 1000}}
+	_, _, err = dec.Decode(encoded)
+	if err == nil || !strings.Contains(err.Error(), "MaxStringBytes exceeded") {
+		t.Fatalf("expected a MaxStringBytes LimitError from the symbol table, got %v", err)
+	}
+}
+
+func TestEncoderRejectsOversizedListUnderCustomLimits(t *testing.T) {
+	data := NewData(int32(1), int32(2), int32(3))
+
+	enc := &Encoder{Limits: DecoderLimits{MaxListLen: 2, MaxStringBytes: 100}}
+	_, err := enc.Encode(data)
+	limitErr, ok := err.(*LimitError)
+	if !ok {
+		t.Fatalf("expected *LimitError, got %T: %v", err, err)
+	}
+	if limitErr.Limit != "MaxListLen" {
+		t.Errorf("expected MaxListLen limit, got %q", limitErr.Limit)
+	}
+}
@@ -0,0 +1,220 @@
+package claudecodec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// ----------------------------------------------------------------------------
+// Framed streaming codec
+// ----------------------------------------------------------------------------
+// StreamEncoder/StreamDecoder let a sequence of Data messages be written to
+// and read from an io.Writer/io.Reader, so the codec can be used as a
+// pipe or network protocol rather than a one-shot, whole-buffer serializer.
+//
+// Stream layout:
+//
+//	header: [magic:4]"CCS1"[version:1][flags:1]
+//	frame:  [length:4][crc32c:4][payload:length]
+//
+// payload is [frameType:1][data...]. frameTypeData payloads hold one value
+// encoded with AppendEncode. Frame types in frameTypeReservedMin..0xFF are
+// reserved for future use: the decoder verifies their CRC, skips the
+// payload, and keeps reading. Any other unrecognized frame type is an error,
+// so genuinely new data kinds must be introduced in the reserved range.
+// ----------------------------------------------------------------------------
+
+var streamMagic = [4]byte{'C', 'C', 'S', '1'}
+
+const streamVersion byte = 1
+
+const (
+	frameTypeData        byte = 0x01
+	frameTypeReservedMin byte = 0x80
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// maxFramePayload bounds the payload size readFrame will allocate for
+// before the CRC is even checked. Unlike gptcodec's sibling StreamReader,
+// whose 3-byte chunk length field caps a chunk at 16 MiB for free,
+// claudecodec's 4-byte length field can claim up to ~4 GiB, so the cap has
+// to be enforced explicitly here instead of falling out of the wire format.
+const maxFramePayload = 64 * 1024 * 1024 // 64 MiB
+
+// FrameCRCError indicates a stream frame failed its CRC32C integrity check.
+// Callers can use this to resynchronize by scanning for the next valid frame
+// boundary instead of aborting the whole stream.
+type FrameCRCError struct {
+	Want uint32
+	Got  uint32
+}
+
+func (e *FrameCRCError) Error() string {
+	return fmt.Sprintf("frame crc mismatch: want 0x%08x, got 0x%08x", e.Want, e.Got)
+}
+
+// StreamEncoder writes a sequence of Data messages to an io.Writer as framed
+// blocks, writing the stream header once before the first frame.
+type StreamEncoder struct {
+	w           io.Writer
+	wroteHeader bool
+}
+
+// NewStreamEncoder creates a StreamEncoder that writes to w.
+func NewStreamEncoder(w io.Writer) *StreamEncoder {
+	return &StreamEncoder{w: w}
+}
+
+// Encode writes data as the next frame in the stream.
+func (e *StreamEncoder) Encode(data Data) error {
+	if !e.wroteHeader {
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	payload, err := AppendEncode([]byte{frameTypeData}, data)
+	if err != nil {
+		return err
+	}
+	return e.writeFrame(payload)
+}
+
+func (e *StreamEncoder) writeHeader() error {
+	hdr := make([]byte, 0, 6)
+	hdr = append(hdr, streamMagic[:]...)
+	hdr = append(hdr, streamVersion, 0) // flags: reserved, currently unused
+	if _, err := e.w.Write(hdr); err != nil {
+		return fmt.Errorf("failed to write stream header: %w", err)
+	}
+	e.wroteHeader = true
+	return nil
+}
+
+func (e *StreamEncoder) writeFrame(payload []byte) error {
+	var lenAndCRC [8]byte
+	binary.LittleEndian.PutUint32(lenAndCRC[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(lenAndCRC[4:8], crc32.Checksum(payload, crc32cTable))
+
+	if _, err := e.w.Write(lenAndCRC[:]); err != nil {
+		return fmt.Errorf("failed to write frame header: %w", err)
+	}
+	if _, err := e.w.Write(payload); err != nil {
+		return fmt.Errorf("failed to write frame payload: %w", err)
+	}
+	return nil
+}
+
+// Flush flushes the underlying writer if it supports flushing.
+func (e *StreamEncoder) Flush() error {
+	if f, ok := e.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close closes the underlying writer if it supports closing.
+func (e *StreamEncoder) Close() error {
+	if c, ok := e.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// StreamDecoder reads a sequence of Data messages previously written by a
+// StreamEncoder from an io.Reader.
+type StreamDecoder struct {
+	r          io.Reader
+	readHeader bool
+}
+
+// NewStreamDecoder creates a StreamDecoder that reads from r.
+func NewStreamDecoder(r io.Reader) *StreamDecoder {
+	return &StreamDecoder{r: r}
+}
+
+// Decode reads and returns the next Data message in the stream, skipping any
+// reserved frame types along the way. It returns io.EOF once the stream ends
+// cleanly on a frame boundary.
+func (d *StreamDecoder) Decode() (Data, error) {
+	if !d.readHeader {
+		if err := d.readStreamHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		payload, err := d.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(payload) == 0 {
+			return nil, fmt.Errorf("frame payload missing type byte")
+		}
+
+		frameType, body := payload[0], payload[1:]
+		if frameType == frameTypeData {
+			value, _, err := Decode(body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode data frame: %w", err)
+			}
+			return value, nil
+		}
+
+		if frameType < frameTypeReservedMin {
+			return nil, fmt.Errorf("unknown frame type: 0x%02x", frameType)
+		}
+		// Reserved frame type: CRC already verified by readFrame, discard it.
+	}
+}
+
+func (d *StreamDecoder) readStreamHeader() error {
+	var hdr [6]byte
+	if _, err := io.ReadFull(d.r, hdr[:]); err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		return fmt.Errorf("failed to read stream header: %w", err)
+	}
+
+	if hdr[0] != streamMagic[0] || hdr[1] != streamMagic[1] || hdr[2] != streamMagic[2] || hdr[3] != streamMagic[3] {
+		return fmt.Errorf("not a claudecodec stream: bad magic")
+	}
+	if hdr[4] != streamVersion {
+		return fmt.Errorf("unsupported stream version: %d", hdr[4])
+	}
+
+	d.readHeader = true
+	return nil
+}
+
+func (d *StreamDecoder) readFrame() ([]byte, error) {
+	var lenAndCRC [8]byte
+	if _, err := io.ReadFull(d.r, lenAndCRC[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read frame header: %w", err)
+	}
+	length := binary.LittleEndian.Uint32(lenAndCRC[0:4])
+	wantCRC := binary.LittleEndian.Uint32(lenAndCRC[4:8])
+
+	if length > maxFramePayload {
+		return nil, fmt.Errorf("frame payload of %d bytes exceeds maximum of %d", length, maxFramePayload)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(d.r, payload); err != nil {
+		return nil, fmt.Errorf("failed to read frame payload: %w", err)
+	}
+
+	if gotCRC := crc32.Checksum(payload, crc32cTable); gotCRC != wantCRC {
+		return nil, &FrameCRCError{Want: wantCRC, Got: gotCRC}
+	}
+
+	return payload, nil
+}
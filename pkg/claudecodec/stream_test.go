@@ -0,0 +1,81 @@
+package claudecodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreamRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewStreamEncoder(&buf)
+
+	messages := []Data{
+		NewData("foo", int32(42)),
+		NewData("bar", NewData("baz", int32(-1))),
+		NewData(),
+	}
+
+	for _, msg := range messages {
+		if err := enc.Encode(msg); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+
+	dec := NewStreamDecoder(&buf)
+	for i, want := range messages {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("Decode message %d: %v", i, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("message %d: want %v, got %v", i, want, got)
+		}
+	}
+
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF at end of stream, got %v", err)
+	}
+}
+
+func TestStreamCRCMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewStreamEncoder(&buf)
+	if err := enc.Encode(NewData("hello")); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	// Flip a byte in the payload so the CRC no longer matches.
+	corrupted := buf.Bytes()
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	dec := NewStreamDecoder(bytes.NewReader(corrupted))
+	_, err := dec.Decode()
+	if _, ok := err.(*FrameCRCError); !ok {
+		t.Fatalf("expected *FrameCRCError, got %T: %v", err, err)
+	}
+}
+
+func TestStreamRejectsOversizedFrameLengthBeforeAllocating(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(streamMagic[:])
+	buf.WriteByte(streamVersion)
+	buf.WriteByte(0) // flags
+
+	var lenAndCRC [8]byte
+	binary.LittleEndian.PutUint32(lenAndCRC[0:4], maxFramePayload+1)
+	binary.LittleEndian.PutUint32(lenAndCRC[4:8], 0)
+	buf.Write(lenAndCRC[:])
+	// Deliberately no payload bytes: if readFrame allocated based on the
+	// claimed length before checking it, it would allocate the oversized
+	// buffer and then fail with a truncated-payload error instead of the
+	// size-limit error.
+
+	dec := NewStreamDecoder(&buf)
+	_, err := dec.Decode()
+	if err == nil || !strings.Contains(err.Error(), "exceeds maximum") {
+		t.Fatalf("expected a frame-too-large error, got %v", err)
+	}
+}
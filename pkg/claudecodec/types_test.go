@@ -0,0 +1,46 @@
+package claudecodec
+
+import "testing"
+
+func TestExtendedTypesRoundTrip(t *testing.T) {
+	data := NewData(
+		int64(-9223372036854775808),
+		3.14159265358979,
+		true,
+		false,
+		nil,
+		[]byte{0x00, 0x01, 0xFF},
+		map[string]interface{}{"a": int32(1), "b": "two"},
+	)
+
+	for _, opts := range []EncodeOpts{{}, {Varint: true}} {
+		encoded, err := AppendEncodeOpts(nil, data, opts)
+		if err != nil {
+			t.Fatalf("AppendEncodeOpts(%+v): %v", opts, err)
+		}
+
+		decoded, _, err := Decode(encoded)
+		if err != nil {
+			t.Fatalf("Decode(%+v): %v", opts, err)
+		}
+
+		if len(decoded) != len(data) {
+			t.Fatalf("opts %+v: expected %d elements, got %d", opts, len(data), len(decoded))
+		}
+		if decoded[2] != true || decoded[3] != false || decoded[4] != nil {
+			t.Errorf("opts %+v: bool/null values did not round-trip: %v", opts, decoded)
+		}
+		m, ok := decoded[6].(map[string]interface{})
+		if !ok || m["b"] != "two" {
+			t.Errorf("opts %+v: map did not round-trip: %v", opts, decoded[6])
+		}
+	}
+}
+
+func TestUnknownTypeByteError(t *testing.T) {
+	// A buffer with a valid format version but an unrecognized type tag.
+	_, _, err := Decode([]byte{FormatFixed, 0xEE})
+	if _, ok := err.(*UnknownTypeError); !ok {
+		t.Fatalf("expected *UnknownTypeError, got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,302 @@
+package claudecodec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------------------------
+// Varint wire dialect
+// ----------------------------------------------------------------------------
+// appendValueVarint/decodeValueVarint are an alternate encoding of the same
+// type-tagged tree as appendValue/decodeValue: int32 payloads, string length
+// prefixes, and list element counts use encoding/binary's varint/uvarint
+// form instead of fixed 4-byte little-endian fields. Small non-negative
+// values and short strings dominate most real payloads, so this typically
+// shrinks the encoded size considerably.
+//
+// This dialect is incompatible with zerocopycodec's zero-copy string
+// aliasing, which depends on a string's bytes sitting at a fixed 4-byte
+// offset past its length prefix - a variable-width length prefix breaks
+// that assumption, so zerocopycodec keeps using the fixed-width layout.
+// ----------------------------------------------------------------------------
+
+// appendValueVarint recursively encodes a single value using the varint wire
+// dialect by appending to dst.
+func appendValueVarint(dst []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case int32:
+		// Format: [TypeInt32:1][value:varint]
+		dst = append(dst, TypeInt32)
+		return binary.AppendVarint(dst, int64(v)), nil
+
+	case string:
+		if !utf8.ValidString(v) {
+			return nil, fmt.Errorf("invalid UTF-8 string")
+		}
+		if len(v) > 1000000 {
+			return nil, fmt.Errorf("string exceeds maximum length of 1,000,000 bytes")
+		}
+		// Format: [TypeString:1][length:uvarint][utf8_bytes:length]
+		dst = append(dst, TypeString)
+		dst = binary.AppendUvarint(dst, uint64(len(v)))
+		return append(dst, v...), nil
+
+	case Data:
+		if len(v) > 1000 {
+			return nil, fmt.Errorf("list exceeds maximum length of 1000")
+		}
+		// Format: [TypeList:1][count:uvarint][element1][element2]...[elementN]
+		dst = append(dst, TypeList)
+		dst = binary.AppendUvarint(dst, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			dst, err = appendValueVarint(dst, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+
+	case []interface{}:
+		return appendValueVarint(dst, Data(v))
+
+	case int64:
+		dst = append(dst, TypeInt64)
+		return binary.AppendVarint(dst, v), nil
+
+	case float64:
+		dst = append(dst, TypeFloat64)
+		return binary.LittleEndian.AppendUint64(dst, math.Float64bits(v)), nil
+
+	case bool:
+		dst = append(dst, TypeBool)
+		if v {
+			return append(dst, 1), nil
+		}
+		return append(dst, 0), nil
+
+	case nil:
+		return append(dst, TypeNull), nil
+
+	case []byte:
+		if len(v) > 1000000 {
+			return nil, fmt.Errorf("bytes exceeds maximum length of 1,000,000 bytes")
+		}
+		dst = append(dst, TypeBytes)
+		dst = binary.AppendUvarint(dst, uint64(len(v)))
+		return append(dst, v...), nil
+
+	case map[string]interface{}:
+		if len(v) > 1000 {
+			return nil, fmt.Errorf("map exceeds maximum length of 1000")
+		}
+		dst = append(dst, TypeMap)
+		dst = binary.AppendUvarint(dst, uint64(len(v)))
+		for key, val := range v {
+			var err error
+			dst, err = appendValueVarint(dst, key)
+			if err != nil {
+				return nil, err
+			}
+			dst, err = appendValueVarint(dst, val)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type: %T", v)
+	}
+}
+
+// varintCursor tracks a read position into a varint-dialect buffer. Unlike
+// the fixed dialect's bytes.Reader, field widths here aren't known up
+// front, so decoding tracks its own offset directly into the slice.
+type varintCursor struct {
+	buf []byte
+	off int
+}
+
+func (c *varintCursor) readByte() (byte, error) {
+	if c.off >= len(c.buf) {
+		return 0, fmt.Errorf("unexpected end of buffer")
+	}
+	b := c.buf[c.off]
+	c.off++
+	return b, nil
+}
+
+func (c *varintCursor) readN(n int) ([]byte, error) {
+	if c.off+n > len(c.buf) {
+		return nil, fmt.Errorf("unexpected end of buffer")
+	}
+	b := c.buf[c.off : c.off+n]
+	c.off += n
+	return b, nil
+}
+
+func (c *varintCursor) readUvarint() (uint64, error) {
+	v, n := binary.Uvarint(c.buf[c.off:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid uvarint field")
+	}
+	c.off += n
+	return v, nil
+}
+
+func (c *varintCursor) readVarint() (int64, error) {
+	v, n := binary.Varint(c.buf[c.off:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint field")
+	}
+	c.off += n
+	return v, nil
+}
+
+// decodeValueVarint decodes a single value from the front of buf using the
+// varint wire dialect, returning the value and the number of bytes consumed.
+// limits bounds string/bytes/list/map sizes and nesting depth exactly like
+// decodeValue does for the fixed dialect.
+func decodeValueVarint(buf []byte, limits DecoderLimits) (interface{}, int, error) {
+	c := &varintCursor{buf: buf}
+	value, err := c.decodeValue(limits, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, c.off, nil
+}
+
+// decodeValue recursively decodes a single value. depth is the nesting
+// depth of this call (the root value is depth 1), checked against
+// limits.MaxDepth to bound the recursion before it reaches the Go stack -
+// mirroring decodeValue's depth guard for the fixed dialect in
+// claude_codec.go.
+func (c *varintCursor) decodeValue(limits DecoderLimits, depth int) (interface{}, error) {
+	if depth > limits.MaxDepth {
+		return nil, &LimitError{Limit: "MaxDepth", Got: depth, Max: limits.MaxDepth}
+	}
+
+	typeByte, err := c.readByte()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read type byte: %v", err)
+	}
+
+	switch typeByte {
+	case TypeInt32:
+		v, err := c.readVarint()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read int32: %v", err)
+		}
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("int32 value %d out of range", v)
+		}
+		return int32(v), nil
+
+	case TypeString:
+		length, err := c.readUvarint()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read string length: %v", err)
+		}
+		if length > uint64(limits.MaxStringBytes) {
+			return nil, &LimitError{Limit: "MaxStringBytes", Got: int(length), Max: limits.MaxStringBytes}
+		}
+		strBytes, err := c.readN(int(length))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read string data: %v", err)
+		}
+		str := string(strBytes)
+		if !utf8.ValidString(str) {
+			return nil, fmt.Errorf("invalid UTF-8 string")
+		}
+		return str, nil
+
+	case TypeList:
+		count, err := c.readUvarint()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read list count: %v", err)
+		}
+		if count > uint64(limits.MaxListLen) {
+			return nil, &LimitError{Limit: "MaxListLen", Got: int(count), Max: limits.MaxListLen}
+		}
+		result := make(Data, count)
+		for i := uint64(0); i < count; i++ {
+			value, err := c.decodeValue(limits, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode list element %d: %v", i, err)
+			}
+			result[i] = value
+		}
+		return result, nil
+
+	case TypeInt64:
+		v, err := c.readVarint()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read int64: %v", err)
+		}
+		return v, nil
+
+	case TypeFloat64:
+		bits, err := c.readN(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read float64: %v", err)
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(bits)), nil
+
+	case TypeBool:
+		b, err := c.readByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bool: %v", err)
+		}
+		return b != 0, nil
+
+	case TypeNull:
+		return nil, nil
+
+	case TypeBytes:
+		length, err := c.readUvarint()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bytes length: %v", err)
+		}
+		if length > uint64(limits.MaxStringBytes) {
+			return nil, &LimitError{Limit: "MaxStringBytes", Got: int(length), Max: limits.MaxStringBytes}
+		}
+		raw, err := c.readN(int(length))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bytes data: %v", err)
+		}
+		return append([]byte(nil), raw...), nil
+
+	case TypeMap:
+		count, err := c.readUvarint()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read map count: %v", err)
+		}
+		if count > uint64(limits.MaxListLen) {
+			return nil, &LimitError{Limit: "MaxListLen", Got: int(count), Max: limits.MaxListLen}
+		}
+		result := make(map[string]interface{}, count)
+		for i := uint64(0); i < count; i++ {
+			keyValue, err := c.decodeValue(limits, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode map key %d: %v", i, err)
+			}
+			key, ok := keyValue.(string)
+			if !ok {
+				return nil, fmt.Errorf("map key %d must be a string", i)
+			}
+			value, err := c.decodeValue(limits, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode map value %d: %v", i, err)
+			}
+			result[key] = value
+		}
+		return result, nil
+
+	default:
+		return nil, &UnknownTypeError{TypeByte: typeByte}
+	}
+}
@@ -0,0 +1,44 @@
+package claudecodec
+
+import "testing"
+
+func TestVarintRoundTrip(t *testing.T) {
+	data := NewData("hello", int32(42), NewData("nested", int32(-7)))
+
+	encoded, err := EncodeVarint(data)
+	if err != nil {
+		t.Fatalf("EncodeVarint: %v", err)
+	}
+
+	decoded, _, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if decoded.String() != data.String() {
+		t.Errorf("round trip mismatch: want %v, got %v", data, decoded)
+	}
+}
+
+func TestVarintSmallerThanFixedForSmallValues(t *testing.T) {
+	data := generateSimpleData(50)
+
+	fixed, err := Encode(data)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	varint, err := EncodeVarint(data)
+	if err != nil {
+		t.Fatalf("EncodeVarint: %v", err)
+	}
+
+	if len(varint) >= len(fixed) {
+		t.Errorf("expected varint encoding (%d bytes) to be smaller than fixed (%d bytes)", len(varint), len(fixed))
+	}
+}
+
+func TestDecodeUnknownFormatVersion(t *testing.T) {
+	if _, _, err := Decode([]byte{0xFF, 0x03}); err == nil {
+		t.Error("expected error for unknown format version byte")
+	}
+}
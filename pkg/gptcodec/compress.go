@@ -0,0 +1,288 @@
+package gptcodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// ----------------------------------------------------------------------------
+// Transparent string compression
+// ----------------------------------------------------------------------------
+// EncodeCompressed encodes like Encode, but strings at or above a
+// configurable threshold are written as TagCompressedString instead of
+// TagString: [Tag:1][UncompressedLen:4][CompressedLen:4][compressed bytes],
+// keeping the fixed 4-byte field convention the rest of this format uses.
+// Decode handles TagCompressedString transparently alongside the plain
+// TagString, so any caller of Decode reads both old and newly-compressed
+// buffers the same way without opting in to anything.
+//
+// This was asked for in terms of github.com/golang/snappy, but this module
+// has no go.mod and vendors no dependencies, so compression instead reuses
+// the in-tree LZ77-style block compressor (mirroring
+// pkg/claudecodec/compress.go) under the codec name "block". CompressOpts.
+// Codec is still an open string so a real Snappy/S2 binding can replace it
+// later without changing the wire format.
+// ----------------------------------------------------------------------------
+
+const TagCompressedString byte = 'Z'
+
+const defaultCompressMinStringLen = 64
+
+// CompressOpts configures EncodeCompressed.
+type CompressOpts struct {
+	// MinStringLen is the minimum UTF-8 byte length a string must reach
+	// before it is compressed; shorter strings are written as plain
+	// TagString since compression overhead isn't worth it below this
+	// size. Zero uses defaultCompressMinStringLen.
+	MinStringLen int
+
+	// Codec names the compressor to use. Only "block" is implemented;
+	// left open for a real Snappy/S2 binding later.
+	Codec string
+}
+
+func (o CompressOpts) resolve() CompressOpts {
+	if o.MinStringLen == 0 {
+		o.MinStringLen = defaultCompressMinStringLen
+	}
+	if o.Codec == "" {
+		o.Codec = "block"
+	}
+	return o
+}
+
+// EncodeCompressed encodes v like Encode, but strings at or above
+// opts.MinStringLen are written as TagCompressedString.
+func EncodeCompressed(v Data, opts CompressOpts) ([]byte, error) {
+	opts = opts.resolve()
+	if opts.Codec != "block" {
+		return nil, fmt.Errorf("unsupported compression codec %q", opts.Codec)
+	}
+
+	buf := make([]byte, 0, 64)
+	return encodeValueCompressed(buf, v, opts)
+}
+
+func encodeValueCompressed(dst []byte, v Data, opts CompressOpts) ([]byte, error) {
+	switch x := v.(type) {
+	case string:
+		bs := []byte(x)
+		if len(bs) > MaxStringLen {
+			return nil, fmt.Errorf("string too long: %d > %d", len(bs), MaxStringLen)
+		}
+		if len(bs) < opts.MinStringLen {
+			dst = append(dst, TagString)
+			dst = writeU32(dst, uint32(len(bs)))
+			return append(dst, bs...), nil
+		}
+
+		compressed := compressBlock(bs)
+		dst = append(dst, TagCompressedString)
+		dst = writeU32(dst, uint32(len(bs)))
+		dst = writeU32(dst, uint32(len(compressed)))
+		return append(dst, compressed...), nil
+
+	case int32:
+		dst = append(dst, TagInt32)
+		dst = writeU32(dst, 4)
+		return writeI32(dst, x), nil
+
+	case int64:
+		dst = append(dst, TagInt64)
+		dst = writeU32(dst, 8)
+		return writeU64(dst, uint64(x)), nil
+
+	case float64:
+		dst = append(dst, TagFloat64)
+		dst = writeU32(dst, 8)
+		return writeU64(dst, math.Float64bits(x)), nil
+
+	case bool:
+		dst = append(dst, TagBool)
+		dst = writeU32(dst, 1)
+		if x {
+			return append(dst, 0x01), nil
+		}
+		return append(dst, 0x00), nil
+
+	case nil:
+		dst = append(dst, TagNull)
+		return writeU32(dst, 0), nil
+
+	case []Data:
+		if len(x) > MaxListLen {
+			return nil, fmt.Errorf("list too long: %d > %d", len(x), MaxListLen)
+		}
+		dst = append(dst, TagList)
+		dst = writeU32(dst, uint32(len(x)))
+		for _, elem := range x {
+			var err error
+			dst, err = encodeValueCompressed(dst, elem, opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+
+	case map[string]Data:
+		if len(x) > MaxMapLen {
+			return nil, fmt.Errorf("map too long: %d > %d", len(x), MaxMapLen)
+		}
+		dst = append(dst, TagMap)
+		dst = writeU32(dst, uint32(len(x)))
+		for _, key := range sortedKeys(x) {
+			var err error
+			dst, err = encodeValueCompressed(dst, key, opts)
+			if err != nil {
+				return nil, err
+			}
+			dst, err = encodeValueCompressed(dst, x[key], opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type %T (allowed: string | int32 | int64 | float64 | bool | nil | []Data | map[string]Data)", v)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// In-tree LZ77-style block compressor
+// ----------------------------------------------------------------------------
+// Same design as claudecodec's compressor: a single-pass matcher over a
+// 16 KiB lookback window producing a sequence of opcodes, each either a
+// literal run ([opLiteral][len:uvarint][bytes]) or a back-reference
+// ([opMatch][offset:uvarint][length:uvarint]).
+// ----------------------------------------------------------------------------
+
+const (
+	compressWindowSize = 16 * 1024
+	compressMinMatch   = 4
+	compressHashBits   = 15
+)
+
+const (
+	opLiteral byte = 0x00
+	opMatch   byte = 0x01
+)
+
+// compressBlock compresses src, returning the compressed opcode stream.
+func compressBlock(src []byte) []byte {
+	var dst []byte
+	hashTable := make(map[uint32]int)
+
+	litStart := 0
+	i := 0
+
+	flushLiteral := func(end int) {
+		if end <= litStart {
+			return
+		}
+		dst = append(dst, opLiteral)
+		dst = binary.AppendUvarint(dst, uint64(end-litStart))
+		dst = append(dst, src[litStart:end]...)
+	}
+
+	for i+compressMinMatch <= len(src) {
+		h := hashFour(src[i:])
+		candidate, seen := hashTable[h]
+		hashTable[h] = i
+
+		if seen && i-candidate <= compressWindowSize &&
+			bytes.Equal(src[candidate:candidate+compressMinMatch], src[i:i+compressMinMatch]) {
+			matchLen := compressMinMatch
+			for i+matchLen < len(src) && src[candidate+matchLen] == src[i+matchLen] {
+				matchLen++
+			}
+
+			flushLiteral(i)
+			dst = append(dst, opMatch)
+			dst = binary.AppendUvarint(dst, uint64(i-candidate))
+			dst = binary.AppendUvarint(dst, uint64(matchLen))
+
+			i += matchLen
+			litStart = i
+			continue
+		}
+
+		i++
+	}
+
+	flushLiteral(len(src))
+	return dst
+}
+
+// hashFour hashes the first 4 bytes of b into a compressHashBits-wide bucket.
+func hashFour(b []byte) uint32 {
+	v := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	return (v * 2654435761) >> (32 - compressHashBits)
+}
+
+// decompressBlock reverses compressBlock, checking the result matches
+// uncompressedLen exactly. Every literal run and match length is bounded
+// against the uncompressed bytes remaining before it is copied, so a
+// forged opcode claiming an oversized run can't grow dst past
+// uncompressedLen before the mismatch is ever detected.
+func decompressBlock(src []byte, uncompressedLen int) ([]byte, error) {
+	dst := make([]byte, 0, uncompressedLen)
+	i := 0
+
+	for i < len(src) {
+		op := src[i]
+		i++
+
+		switch op {
+		case opLiteral:
+			n, k := binary.Uvarint(src[i:])
+			if k <= 0 {
+				return nil, fmt.Errorf("invalid literal run length")
+			}
+			i += k
+			if n > uint64(uncompressedLen-len(dst)) {
+				return nil, fmt.Errorf("literal run of %d bytes overruns uncompressed length %d", n, uncompressedLen)
+			}
+			if i+int(n) > len(src) {
+				return nil, fmt.Errorf("truncated literal run")
+			}
+			dst = append(dst, src[i:i+int(n)]...)
+			i += int(n)
+
+		case opMatch:
+			offset, k := binary.Uvarint(src[i:])
+			if k <= 0 {
+				return nil, fmt.Errorf("invalid match offset")
+			}
+			i += k
+
+			length, k2 := binary.Uvarint(src[i:])
+			if k2 <= 0 {
+				return nil, fmt.Errorf("invalid match length")
+			}
+			i += k2
+			if length > uint64(uncompressedLen-len(dst)) {
+				return nil, fmt.Errorf("match of %d bytes overruns uncompressed length %d", length, uncompressedLen)
+			}
+
+			start := len(dst) - int(offset)
+			if offset == 0 || start < 0 {
+				return nil, fmt.Errorf("match offset out of range")
+			}
+			for j := 0; j < int(length); j++ {
+				dst = append(dst, dst[start+j])
+			}
+
+		default:
+			return nil, fmt.Errorf("invalid compressed block opcode: 0x%02x", op)
+		}
+	}
+
+	if len(dst) != uncompressedLen {
+		return nil, fmt.Errorf("decompressed length mismatch: want %d, got %d", uncompressedLen, len(dst))
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,104 @@
+package gptcodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompressedRoundTrips(t *testing.T) {
+	long := strings.Repeat("hello world ", 50)
+	cases := []Data{
+		"short",
+		long,
+		[]Data{long, int32(7), "short"},
+	}
+	for _, cse := range cases {
+		b, err := EncodeCompressed(cse, CompressOpts{})
+		if err != nil {
+			t.Fatalf("EncodeCompressed error: %v", err)
+		}
+		out, err := Decode(b)
+		if err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		if !reflect.DeepEqual(out, cse) {
+			t.Fatalf("mismatch: want %#v got %#v", cse, out)
+		}
+	}
+}
+
+func TestCompressedLeavesShortStringsPlain(t *testing.T) {
+	b, err := EncodeCompressed("short", CompressOpts{MinStringLen: 1000})
+	if err != nil {
+		t.Fatalf("EncodeCompressed error: %v", err)
+	}
+	if b[0] != TagString {
+		t.Fatalf("expected plain TagString, got tag 0x%02x", b[0])
+	}
+}
+
+func TestCompressedUsesCompressedTagAboveThreshold(t *testing.T) {
+	long := strings.Repeat("ab", 100)
+	b, err := EncodeCompressed(long, CompressOpts{MinStringLen: 10})
+	if err != nil {
+		t.Fatalf("EncodeCompressed error: %v", err)
+	}
+	if b[0] != TagCompressedString {
+		t.Fatalf("expected TagCompressedString, got tag 0x%02x", b[0])
+	}
+}
+
+func TestCompressedRejectsUnknownCodec(t *testing.T) {
+	if _, err := EncodeCompressed("x", CompressOpts{Codec: "zstd"}); err == nil {
+		t.Fatal("expected an error for an unimplemented codec")
+	}
+}
+
+func TestCompressedRejectsOversizedUncompressedLen(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteByte(TagCompressedString)
+	buf.Write(writeU32(nil, uint32(MaxStringLen+1)))
+	buf.Write(writeU32(nil, 0))
+
+	if _, err := Decode(buf.Bytes()); err == nil {
+		t.Fatal("expected an error for an oversized uncompressed length")
+	}
+}
+
+func TestDecompressBlockRejectsMatchLengthOverrunningUncompressedLen(t *testing.T) {
+	// A forged opMatch claiming a back-reference far longer than the
+	// declared uncompressed length must be rejected immediately rather
+	// than growing dst out to the claimed length first.
+	var block []byte
+	block = append(block, opMatch)
+	block = binary.AppendUvarint(block, 1)     // offset
+	block = binary.AppendUvarint(block, 1<<33) // length: wildly oversized
+
+	_, err := decompressBlock(block, 10)
+	if err == nil || !strings.Contains(err.Error(), "overruns uncompressed length") {
+		t.Fatalf("expected an overrun error, got %v", err)
+	}
+}
+
+func TestDecodeLimitedRejectsForgedCompressedStringMatchLength(t *testing.T) {
+	// A TagCompressedString payload whose body contains a single opMatch
+	// claiming an oversized length must not defeat DecodeLimited's budget
+	// accounting by hanging inside decompressBlock.
+	var compressedBody []byte
+	compressedBody = append(compressedBody, opMatch)
+	compressedBody = binary.AppendUvarint(compressedBody, 1)
+	compressedBody = binary.AppendUvarint(compressedBody, 1<<33)
+
+	var buf []byte
+	buf = append(buf, TagCompressedString)
+	buf = writeU32(buf, 10) // uncompressedLen
+	buf = writeU32(buf, uint32(len(compressedBody)))
+	buf = append(buf, compressedBody...)
+
+	if _, err := DecodeLimited(buf, DefaultLimits); err == nil {
+		t.Fatal("expected an error for a forged oversized match length")
+	}
+}
@@ -0,0 +1,166 @@
+package gptcodec
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func FuzzDecodeLimited(f *testing.F) {
+	f.Add([]byte{TagString, 0, 0, 0, 3, 'f', 'o', 'o'})
+	f.Add([]byte{TagList, 0, 0, 0, 2,
+		TagInt32, 0, 0, 0, 4, 0, 0, 0, 1,
+		TagInt32, 0, 0, 0, 4, 0, 0, 0, 2})
+	f.Add([]byte{TagList, 0xff, 0xff, 0xff, 0xff})
+	f.Add([]byte{TagCompressedString, 0, 0, 0, 0, 0, 0, 0, 0})
+	// A forged opMatch claiming a back-reference far longer than the
+	// declared uncompressed length - regression seed for the decompressBlock
+	// overrun bug DecodeLimited's budget accounting couldn't see through.
+	f.Add([]byte{
+		TagCompressedString,
+		0, 0, 0, 10, // uncompressedLen
+		0, 0, 0, 11, // compressedLen
+		opMatch, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f, // offset=1, length=1<<63-1
+	})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("DecodeLimited panicked on %v: %v", b, r)
+			}
+		}()
+		_, _ = DecodeLimited(b, DefaultLimits)
+	})
+}
+
+// randomData builds a random Data tree of bounded depth for round-trip
+// testing; it never exceeds DefaultLimits, so every value it produces must
+// round-trip successfully.
+func randomData(r *rand.Rand, depth int) Data {
+	if depth >= 4 || r.Intn(3) == 0 {
+		switch r.Intn(5) {
+		case 0:
+			return randomString(r)
+		case 1:
+			return int32(r.Intn(2000) - 1000)
+		case 2:
+			return r.Int63()
+		case 3:
+			return r.Float64()
+		case 4:
+			return r.Intn(2) == 0
+		}
+	}
+	if r.Intn(5) == 0 {
+		return nil
+	}
+
+	if r.Intn(2) == 0 {
+		n := r.Intn(4)
+		m := make(map[string]Data, n)
+		for i := 0; i < n; i++ {
+			m[randomString(r)+string(rune('a'+i))] = randomData(r, depth+1)
+		}
+		return m
+	}
+
+	n := r.Intn(4)
+	list := make([]Data, n)
+	for i := range list {
+		list[i] = randomData(r, depth+1)
+	}
+	return list
+}
+
+func randomString(r *rand.Rand) string {
+	n := r.Intn(20)
+	bs := make([]byte, n)
+	for i := range bs {
+		bs[i] = byte('a' + r.Intn(26))
+	}
+	return string(bs)
+}
+
+func TestDecodeLimitedRoundTripsRandomData(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		v := randomData(r, 0)
+
+		encoded, err := Encode(v)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+
+		decoded, err := DecodeLimited(encoded, DefaultLimits)
+		if err != nil {
+			t.Fatalf("DecodeLimited: %v", err)
+		}
+		if !reflect.DeepEqual(decoded, v) {
+			t.Fatalf("mismatch: want %#v got %#v", v, decoded)
+		}
+	}
+}
+
+func TestDecodeLimitedRejectsExcessiveDepth(t *testing.T) {
+	nested := Data([]Data{[]Data{[]Data{"deep"}}})
+
+	encoded, err := Encode(nested)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	_, err = DecodeLimited(encoded, Limits{MaxDepth: 2})
+	if limitErr, ok := err.(*LimitError); !ok || limitErr.Limit != "MaxDepth" {
+		t.Fatalf("expected a MaxDepth LimitError, got %v", err)
+	}
+}
+
+func TestDecodeLimitedRejectsTooManyElements(t *testing.T) {
+	list := make([]Data, 10)
+	for i := range list {
+		list[i] = int32(i)
+	}
+
+	encoded, err := Encode(Data(list))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	_, err = DecodeLimited(encoded, Limits{MaxTotalElements: 5})
+	if limitErr, ok := err.(*LimitError); !ok || limitErr.Limit != "MaxTotalElements" {
+		t.Fatalf("expected a MaxTotalElements LimitError, got %v", err)
+	}
+}
+
+func TestDecodeLimitedRejectsOversizedStringBytes(t *testing.T) {
+	encoded, err := Encode("this string is over the custom limit")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	_, err = DecodeLimited(encoded, Limits{MaxTotalStringBytes: 4})
+	if limitErr, ok := err.(*LimitError); !ok || limitErr.Limit != "MaxTotalStringBytes" {
+		t.Fatalf("expected a MaxTotalStringBytes LimitError, got %v", err)
+	}
+}
+
+func TestDecodeLimitedMatchesDecodeOnValidInput(t *testing.T) {
+	v := []Data{"hello", int32(42), []Data{"nested"}}
+
+	encoded, err := Encode(v)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	viaDecode, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	viaLimited, err := DecodeLimited(encoded, DefaultLimits)
+	if err != nil {
+		t.Fatalf("DecodeLimited: %v", err)
+	}
+	if !reflect.DeepEqual(viaDecode, viaLimited) {
+		t.Fatalf("Decode and DecodeLimited disagree: %#v vs %#v", viaDecode, viaLimited)
+	}
+}
@@ -3,6 +3,8 @@ package gptcodec
 import (
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 )
 
 // ----------------------------------------------------------------------------
@@ -13,9 +15,21 @@ import (
 //   'S' (0x53): String    -> Length = byte length of UTF-8 payload, Payload = bytes
 //   'I' (0x49): Int32     -> Length = 4, Payload = 4 bytes big-endian two's complement
 //   'L' (0x4C): List<Data>-> Length = element count (u32). Then exactly N concatenated TLV values
+//   'J' (0x4A): Int64     -> Length = 8, Payload = 8 bytes big-endian two's complement
+//   'F' (0x46): Float64   -> Length = 8, Payload = 8 bytes big-endian IEEE-754
+//   'B' (0x42): Bool      -> Length = 1, Payload = 0x00 or 0x01
+//   'N' (0x4E): Null      -> Length = 0, no payload
+//   'M' (0x4D): Map<string,Data> -> Length = pair count (u32). Then exactly N
+//               (TLV string key, TLV value) pairs, keys written in sorted
+//               order; Decode rejects unsorted or duplicate keys so a map's
+//               wire form is always canonical
+//   'Z' (0x5A): CompressedString -> no generic Length field; instead
+//               [UncompressedLen:4][CompressedLen:4][compressed bytes]
+//               (see compress.go; produced only by EncodeCompressed, but Decode reads it transparently)
 //
 // Constraints enforced:
 //  - Max list length: 1000
+//  - Max map length: 1000
 //  - Max string length: 1_000_000 bytes
 //
 // Complexity:
@@ -25,15 +39,21 @@ import (
 // ----------------------------------------------------------------------------
 
 const (
-	TagString byte = 'S'
-	TagInt32  byte = 'I'
-	TagList   byte = 'L'
+	TagString  byte = 'S'
+	TagInt32   byte = 'I'
+	TagList    byte = 'L'
+	TagInt64   byte = 'J'
+	TagFloat64 byte = 'F'
+	TagBool    byte = 'B'
+	TagNull    byte = 'N'
+	TagMap     byte = 'M'
 
 	MaxListLen   = 1000
+	MaxMapLen    = 1000
 	MaxStringLen = 1_000_000
 )
 
-// Data is one of: string | int32 | []Data
+// Data is one of: string | int32 | int64 | float64 | bool | nil | []Data | map[string]Data
 // (Using any for simplicity; validate at runtime.)
 type Data = any
 
@@ -56,8 +76,15 @@ func EncodeString(v Data) (string, error) {
 	return string(b), nil
 }
 
-// Decode parses a byte slice produced by Encode and returns Data.
+// Decode parses a byte slice produced by Encode or EncodeWith and returns
+// Data. It auto-detects the varint dialect (see varint.go) by checking for
+// its leading magic byte, which never collides with a fixed-dialect
+// buffer's first byte (always one of the ASCII tag letters above).
 func Decode(b []byte) (Data, error) {
+	if len(b) > 0 && b[0] == dialectMagicVarint {
+		return DecodeWith(b, Options{Varint: true})
+	}
+
 	v, off, err := decodeValue(b, 0)
 	if err != nil {
 		return nil, err
@@ -71,6 +98,26 @@ func Decode(b []byte) (Data, error) {
 // DecodeString mirrors the spec signature.
 func DecodeString(s string) (Data, error) { return Decode([]byte(s)) }
 
+// NewMap builds a map[string]Data from alternating key/value arguments,
+// mirroring claudecodec's NewData helper. It panics if called with an odd
+// number of arguments or a non-string key, since both are programmer errors
+// at the call site rather than something a caller would want to recover
+// from.
+func NewMap(pairs ...any) map[string]Data {
+	if len(pairs)%2 != 0 {
+		panic("gptcodec: NewMap requires an even number of arguments")
+	}
+	m := make(map[string]Data, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			panic(fmt.Sprintf("gptcodec: NewMap key %d must be a string, got %T", i/2, pairs[i]))
+		}
+		m[key] = pairs[i+1]
+	}
+	return m
+}
+
 // ----------------------------------------------------------------------------
 // Encoding helpers
 // ----------------------------------------------------------------------------
@@ -91,6 +138,29 @@ func encodeValue(dst []byte, v Data) ([]byte, error) {
 		dst = writeU32(dst, 4)
 		dst = writeI32(dst, x)
 		return dst, nil
+	case int64:
+		dst = append(dst, TagInt64)
+		dst = writeU32(dst, 8)
+		dst = writeU64(dst, uint64(x))
+		return dst, nil
+	case float64:
+		dst = append(dst, TagFloat64)
+		dst = writeU32(dst, 8)
+		dst = writeU64(dst, math.Float64bits(x))
+		return dst, nil
+	case bool:
+		dst = append(dst, TagBool)
+		dst = writeU32(dst, 1)
+		if x {
+			dst = append(dst, 0x01)
+		} else {
+			dst = append(dst, 0x00)
+		}
+		return dst, nil
+	case nil:
+		dst = append(dst, TagNull)
+		dst = writeU32(dst, 0)
+		return dst, nil
 	case []Data:
 		if len(x) > MaxListLen {
 			return nil, fmt.Errorf("list too long: %d > %d", len(x), MaxListLen)
@@ -105,9 +175,38 @@ func encodeValue(dst []byte, v Data) ([]byte, error) {
 			}
 		}
 		return dst, nil
+	case map[string]Data:
+		if len(x) > MaxMapLen {
+			return nil, fmt.Errorf("map too long: %d > %d", len(x), MaxMapLen)
+		}
+		dst = append(dst, TagMap)
+		dst = writeU32(dst, uint32(len(x))) // pair count
+		for _, key := range sortedKeys(x) {
+			var err error
+			dst, err = encodeValue(dst, key)
+			if err != nil {
+				return nil, err
+			}
+			dst, err = encodeValue(dst, x[key])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
 	default:
-		return nil, fmt.Errorf("unsupported type %T (allowed: string | int32 | []Data)", v)
+		return nil, fmt.Errorf("unsupported type %T (allowed: string | int32 | int64 | float64 | bool | nil | []Data | map[string]Data)", v)
+	}
+}
+
+// sortedKeys returns m's keys in sorted order, so a map always encodes to
+// the same canonical byte sequence.
+func sortedKeys(m map[string]Data) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
 }
 
 func writeU32(dst []byte, v uint32) []byte {
@@ -121,6 +220,13 @@ func writeU32(dst []byte, v uint32) []byte {
 
 func writeI32(dst []byte, v int32) []byte { return writeU32(dst, uint32(v)) }
 
+func writeU64(dst []byte, v uint64) []byte {
+	return append(dst,
+		byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32),
+		byte(v>>24), byte(v>>16), byte(v>>8), byte(v),
+	)
+}
+
 // ----------------------------------------------------------------------------
 // Decoding helpers
 // ----------------------------------------------------------------------------
@@ -164,6 +270,16 @@ func (c *cursor) readN(n int) ([]byte, error) {
 	return v, nil
 }
 
+func (c *cursor) readU64() (uint64, error) {
+	if err := c.need(8); err != nil {
+		return 0, err
+	}
+	b := c.b[c.off : c.off+8]
+	c.off += 8
+	return uint64(b[0])<<56 | uint64(b[1])<<48 | uint64(b[2])<<40 | uint64(b[3])<<32 |
+		uint64(b[4])<<24 | uint64(b[5])<<16 | uint64(b[6])<<8 | uint64(b[7]), nil
+}
+
 func decodeValue(b []byte, start int) (Data, int, error) {
 	c := &cursor{b: b, off: start}
 	tag, err := c.readByte()
@@ -184,6 +300,27 @@ func decodeValue(b []byte, start int) (Data, int, error) {
 			return nil, start, err
 		}
 		return string(payload), c.off, nil
+	case TagCompressedString:
+		uncompressedLen, err := c.readU32()
+		if err != nil {
+			return nil, start, err
+		}
+		if uncompressedLen > MaxStringLen {
+			return nil, start, fmt.Errorf("string too long: %d > %d", uncompressedLen, MaxStringLen)
+		}
+		compressedLen, err := c.readU32()
+		if err != nil {
+			return nil, start, err
+		}
+		compressed, err := c.readN(int(compressedLen))
+		if err != nil {
+			return nil, start, err
+		}
+		raw, err := decompressBlock(compressed, int(uncompressedLen))
+		if err != nil {
+			return nil, start, err
+		}
+		return string(raw), c.off, nil
 	case TagInt32:
 		ln, err := c.readU32()
 		if err != nil {
@@ -197,6 +334,61 @@ func decodeValue(b []byte, start int) (Data, int, error) {
 			return nil, start, err
 		}
 		return int32(u), c.off, nil
+	case TagInt64:
+		ln, err := c.readU32()
+		if err != nil {
+			return nil, start, err
+		}
+		if ln != 8 {
+			return nil, start, fmt.Errorf("int64 length must be 8, got %d", ln)
+		}
+		u, err := c.readU64()
+		if err != nil {
+			return nil, start, err
+		}
+		return int64(u), c.off, nil
+	case TagFloat64:
+		ln, err := c.readU32()
+		if err != nil {
+			return nil, start, err
+		}
+		if ln != 8 {
+			return nil, start, fmt.Errorf("float64 length must be 8, got %d", ln)
+		}
+		u, err := c.readU64()
+		if err != nil {
+			return nil, start, err
+		}
+		return math.Float64frombits(u), c.off, nil
+	case TagBool:
+		ln, err := c.readU32()
+		if err != nil {
+			return nil, start, err
+		}
+		if ln != 1 {
+			return nil, start, fmt.Errorf("bool length must be 1, got %d", ln)
+		}
+		payload, err := c.readN(1)
+		if err != nil {
+			return nil, start, err
+		}
+		switch payload[0] {
+		case 0x00:
+			return false, c.off, nil
+		case 0x01:
+			return true, c.off, nil
+		default:
+			return nil, start, fmt.Errorf("bool payload must be 0x00 or 0x01, got 0x%02x", payload[0])
+		}
+	case TagNull:
+		ln, err := c.readU32()
+		if err != nil {
+			return nil, start, err
+		}
+		if ln != 0 {
+			return nil, start, fmt.Errorf("null length must be 0, got %d", ln)
+		}
+		return nil, c.off, nil
 	case TagList:
 		count, err := c.readU32()
 		if err != nil {
@@ -215,6 +407,45 @@ func decodeValue(b []byte, start int) (Data, int, error) {
 			res = append(res, v)
 		}
 		return res, c.off, nil
+	case TagMap:
+		count, err := c.readU32()
+		if err != nil {
+			return nil, start, err
+		}
+		if count > MaxMapLen {
+			return nil, start, fmt.Errorf("map too long: %d > %d", count, MaxMapLen)
+		}
+		res := make(map[string]Data, count)
+		lastKey := ""
+		haveLastKey := false
+		for i := uint32(0); i < count; i++ {
+			keyVal, off, err := decodeValue(b, c.off)
+			if err != nil {
+				return nil, start, err
+			}
+			c.off = off
+			key, ok := keyVal.(string)
+			if !ok {
+				return nil, start, fmt.Errorf("map key %d must be a string, got %T", i, keyVal)
+			}
+			if haveLastKey {
+				if key == lastKey {
+					return nil, start, fmt.Errorf("map has duplicate key %q", key)
+				}
+				if key < lastKey {
+					return nil, start, fmt.Errorf("map keys are not in sorted order: %q before %q", lastKey, key)
+				}
+			}
+			lastKey, haveLastKey = key, true
+
+			val, off, err := decodeValue(b, c.off)
+			if err != nil {
+				return nil, start, err
+			}
+			c.off = off
+			res[key] = val
+		}
+		return res, c.off, nil
 	default:
 		return nil, start, fmt.Errorf("unknown tag 0x%X at offset %d", tag, start)
 	}
@@ -0,0 +1,349 @@
+package gptcodec
+
+import (
+	"fmt"
+	"math"
+)
+
+// ----------------------------------------------------------------------------
+// Adversarial decode hardening
+// ----------------------------------------------------------------------------
+// DecodeLimited walks a TLV buffer the same way Decode does, but against
+// explicit budgets instead of the package's fixed MaxStringLen/MaxListLen
+// constants, and it never recurses: list and map nesting is tracked on an
+// explicit, heap-allocated stack of frame values instead of the Go
+// call stack. That means a maliciously deep buffer is rejected by the
+// MaxDepth check before it grows the stack at all, rather than relying on
+// the check firing partway through an already-deep recursive call chain.
+// ----------------------------------------------------------------------------
+
+// Limits bounds a DecodeLimited call.
+type Limits struct {
+	// MaxDepth caps list and map nesting depth.
+	MaxDepth int
+
+	// MaxTotalElements caps the total number of list elements and map pairs
+	// across the whole decode, not just within a single list or map.
+	MaxTotalElements int
+
+	// MaxTotalStringBytes caps the sum of all decoded string lengths
+	// (post-decompression, for TagCompressedString) across the whole
+	// decode.
+	MaxTotalStringBytes int
+
+	// MaxAllocBytes caps total bytes allocated for string and compressed
+	// payloads across the whole decode.
+	MaxAllocBytes int
+}
+
+// DefaultLimits are generous limits suitable for trusted input.
+var DefaultLimits = Limits{
+	MaxDepth:            1000,
+	MaxTotalElements:    100000,
+	MaxTotalStringBytes: 10 << 20,
+	MaxAllocBytes:       20 << 20,
+}
+
+// resolve fills zero fields from DefaultLimits, so the zero value of
+// Limits behaves like DefaultLimits.
+func (l Limits) resolve() Limits {
+	if l.MaxDepth == 0 {
+		l.MaxDepth = DefaultLimits.MaxDepth
+	}
+	if l.MaxTotalElements == 0 {
+		l.MaxTotalElements = DefaultLimits.MaxTotalElements
+	}
+	if l.MaxTotalStringBytes == 0 {
+		l.MaxTotalStringBytes = DefaultLimits.MaxTotalStringBytes
+	}
+	if l.MaxAllocBytes == 0 {
+		l.MaxAllocBytes = DefaultLimits.MaxAllocBytes
+	}
+	return l
+}
+
+// LimitError reports which budget DecodeLimited exceeded.
+type LimitError struct {
+	Limit string
+	Got   int
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s exceeded: got %d, max %d", e.Limit, e.Got, e.Max)
+}
+
+// frame is one level of DecodeLimited's explicit decode stack: a list or
+// map that has been opened but not yet fully read. Lists and maps share a
+// frame type rather than getting separate stacks, since at most one is
+// ever in progress at a given depth.
+type frame struct {
+	isMap bool
+
+	// List fields.
+	remaining int
+	elems     []Data
+
+	// Map fields. A map frame alternates between reading a key (haveKey
+	// false) and reading the value for pendingKey (haveKey true);
+	// pairsRemaining only decrements once a full pair lands.
+	pairsRemaining int
+	m              map[string]Data
+	haveKey        bool
+	pendingKey     string
+	haveLastKey    bool
+	lastKey        string
+}
+
+// DecodeLimited parses b like Decode, but enforces limits instead of the
+// package's fixed constants. It reads only the fixed-width dialect: a
+// varint-dialect buffer (see varint.go) fails with an unknown tag error.
+func DecodeLimited(b []byte, limits Limits) (Data, error) {
+	limits = limits.resolve()
+
+	c := &cursor{b: b, off: 0}
+	var stack []*frame
+	var totalElements, totalStringBytes, totalAllocBytes int
+
+	chargeString := func(n int) error {
+		totalStringBytes += n
+		if totalStringBytes > limits.MaxTotalStringBytes {
+			return &LimitError{Limit: "MaxTotalStringBytes", Got: totalStringBytes, Max: limits.MaxTotalStringBytes}
+		}
+		totalAllocBytes += n
+		if totalAllocBytes > limits.MaxAllocBytes {
+			return &LimitError{Limit: "MaxAllocBytes", Got: totalAllocBytes, Max: limits.MaxAllocBytes}
+		}
+		return nil
+	}
+
+	for {
+		start := c.off
+		tag, err := c.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		var value Data
+
+		switch tag {
+		case TagString:
+			ln, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			if err := chargeString(int(ln)); err != nil {
+				return nil, err
+			}
+			payload, err := c.readN(int(ln))
+			if err != nil {
+				return nil, err
+			}
+			value = string(payload)
+
+		case TagCompressedString:
+			uncompressedLen, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			if err := chargeString(int(uncompressedLen)); err != nil {
+				return nil, err
+			}
+			compressedLen, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			totalAllocBytes += int(compressedLen)
+			if totalAllocBytes > limits.MaxAllocBytes {
+				return nil, &LimitError{Limit: "MaxAllocBytes", Got: totalAllocBytes, Max: limits.MaxAllocBytes}
+			}
+			compressed, err := c.readN(int(compressedLen))
+			if err != nil {
+				return nil, err
+			}
+			raw, err := decompressBlock(compressed, int(uncompressedLen))
+			if err != nil {
+				return nil, err
+			}
+			value = string(raw)
+
+		case TagInt32:
+			ln, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			if ln != 4 {
+				return nil, fmt.Errorf("int32 length must be 4, got %d", ln)
+			}
+			u, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			value = int32(u)
+
+		case TagInt64:
+			ln, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			if ln != 8 {
+				return nil, fmt.Errorf("int64 length must be 8, got %d", ln)
+			}
+			u, err := c.readU64()
+			if err != nil {
+				return nil, err
+			}
+			value = int64(u)
+
+		case TagFloat64:
+			ln, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			if ln != 8 {
+				return nil, fmt.Errorf("float64 length must be 8, got %d", ln)
+			}
+			u, err := c.readU64()
+			if err != nil {
+				return nil, err
+			}
+			value = math.Float64frombits(u)
+
+		case TagBool:
+			ln, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			if ln != 1 {
+				return nil, fmt.Errorf("bool length must be 1, got %d", ln)
+			}
+			payload, err := c.readN(1)
+			if err != nil {
+				return nil, err
+			}
+			switch payload[0] {
+			case 0x00:
+				value = false
+			case 0x01:
+				value = true
+			default:
+				return nil, fmt.Errorf("bool payload must be 0x00 or 0x01, got 0x%02x", payload[0])
+			}
+
+		case TagNull:
+			ln, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+			if ln != 0 {
+				return nil, fmt.Errorf("null length must be 0, got %d", ln)
+			}
+			value = nil
+
+		case TagList:
+			count, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+
+			totalElements += int(count)
+			if totalElements > limits.MaxTotalElements {
+				return nil, &LimitError{Limit: "MaxTotalElements", Got: totalElements, Max: limits.MaxTotalElements}
+			}
+
+			depth := len(stack) + 1
+			if depth > limits.MaxDepth {
+				return nil, &LimitError{Limit: "MaxDepth", Got: depth, Max: limits.MaxDepth}
+			}
+
+			if count == 0 {
+				value = []Data{}
+				break
+			}
+
+			stack = append(stack, &frame{remaining: int(count), elems: make([]Data, 0, count)})
+			continue
+
+		case TagMap:
+			count, err := c.readU32()
+			if err != nil {
+				return nil, err
+			}
+
+			totalElements += int(count)
+			if totalElements > limits.MaxTotalElements {
+				return nil, &LimitError{Limit: "MaxTotalElements", Got: totalElements, Max: limits.MaxTotalElements}
+			}
+
+			depth := len(stack) + 1
+			if depth > limits.MaxDepth {
+				return nil, &LimitError{Limit: "MaxDepth", Got: depth, Max: limits.MaxDepth}
+			}
+
+			if count == 0 {
+				value = map[string]Data{}
+				break
+			}
+
+			stack = append(stack, &frame{isMap: true, pairsRemaining: int(count), m: make(map[string]Data, count)})
+			continue
+
+		default:
+			return nil, fmt.Errorf("unknown tag 0x%X at offset %d", tag, start)
+		}
+
+		// Attach value to its parent frame, popping any lists or maps
+		// that value just completed, all the way up to the root.
+		for {
+			if len(stack) == 0 {
+				if c.off != len(b) {
+					return nil, fmt.Errorf("trailing bytes: decoded %d of %d", c.off, len(b))
+				}
+				return value, nil
+			}
+
+			top := stack[len(stack)-1]
+
+			if top.isMap {
+				if !top.haveKey {
+					key, ok := value.(string)
+					if !ok {
+						return nil, fmt.Errorf("map key must be a string, got %T", value)
+					}
+					if top.haveLastKey {
+						if key == top.lastKey {
+							return nil, fmt.Errorf("map has duplicate key %q", key)
+						}
+						if key < top.lastKey {
+							return nil, fmt.Errorf("map keys are not in sorted order: %q before %q", top.lastKey, key)
+						}
+					}
+					top.pendingKey = key
+					top.haveKey = true
+					break
+				}
+
+				top.m[top.pendingKey] = value
+				top.lastKey, top.haveLastKey = top.pendingKey, true
+				top.haveKey = false
+				top.pairsRemaining--
+				if top.pairsRemaining > 0 {
+					break
+				}
+
+				stack = stack[:len(stack)-1]
+				value = top.m
+				continue
+			}
+
+			top.elems = append(top.elems, value)
+			top.remaining--
+			if top.remaining > 0 {
+				break
+			}
+
+			stack = stack[:len(stack)-1]
+			value = top.elems
+		}
+	}
+}
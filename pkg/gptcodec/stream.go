@@ -0,0 +1,201 @@
+package gptcodec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// ----------------------------------------------------------------------------
+// Streaming framed codec
+// ----------------------------------------------------------------------------
+// StreamWriter/StreamReader frame a sequence of TLV values for transport
+// over an io.Writer/io.Reader, modeled on the Snappy framing format: a
+// "stream identifier" chunk followed by self-delimited chunks of
+// [chunkType:1][length:3 little-endian][payload...]. Unlike Snappy, data
+// chunks here are never compressed - each carries exactly one complete TLV
+// value guarded by a masked CRC32C checksum, using the same masking trick
+// Snappy's framing does (rotate right 15 bits, add a fixed constant) so a
+// chunk of all zero bytes doesn't read as a valid checksum.
+//
+// Chunk types:
+//
+//	0x01        data chunk: [CRC32C:4][TLV value]
+//	0x02..0x7f  reserved, unskippable - an unknown reader must treat this as an error
+//	0x80..0xfd  reserved, skippable - an unknown reader discards the chunk and keeps going
+//	0xfe        padding - always skippable, payload ignored
+//	0xff        stream identifier - payload must equal streamIdentifierPayload;
+//	            valid anywhere in the stream, not just at the start (so
+//	            concatenated streams re-synchronize cleanly)
+// ----------------------------------------------------------------------------
+
+const (
+	chunkTypeData             byte = 0x01
+	chunkTypePadding          byte = 0xfe
+	chunkTypeStreamIdentifier byte = 0xff
+)
+
+const streamIdentifierPayload = "cTLV01"
+
+// maxChunkPayload is the largest payload the 3-byte little-endian length
+// field can address.
+const maxChunkPayload = 1<<24 - 1
+
+// crcMaskDelta is Snappy's CRC masking constant.
+const crcMaskDelta = 0xa282ead8
+
+var chunkCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// maskChecksum applies Snappy's CRC masking (rotate right 15 bits, add a
+// fixed constant) to a raw CRC32C checksum.
+func maskChecksum(c uint32) uint32 {
+	return ((c >> 15) | (c << 17)) + crcMaskDelta
+}
+
+// ChunkCRCError indicates a data chunk's checksum did not match its
+// payload, naming the byte offset of the chunk so callers can locate the
+// corruption or resynchronize by scanning for the next valid chunk.
+type ChunkCRCError struct {
+	Offset int
+	Want   uint32
+	Got    uint32
+}
+
+func (e *ChunkCRCError) Error() string {
+	return fmt.Sprintf("chunk at offset %d: crc mismatch: want 0x%08x, got 0x%08x", e.Offset, e.Want, e.Got)
+}
+
+// StreamWriter writes a sequence of TLV values to an io.Writer as framed
+// chunks, writing the stream identifier chunk once before the first value.
+type StreamWriter struct {
+	w           io.Writer
+	wroteHeader bool
+}
+
+// NewStreamWriter creates a StreamWriter that writes to w.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{w: w}
+}
+
+// WriteValue encodes v and writes it as the next data chunk in the stream.
+func (s *StreamWriter) WriteValue(v Data) error {
+	if !s.wroteHeader {
+		if err := s.writeChunk(chunkTypeStreamIdentifier, []byte(streamIdentifierPayload)); err != nil {
+			return err
+		}
+		s.wroteHeader = true
+	}
+
+	encoded, err := Encode(v)
+	if err != nil {
+		return err
+	}
+
+	payload := make([]byte, 4, 4+len(encoded))
+	binary.LittleEndian.PutUint32(payload, maskChecksum(crc32.Checksum(encoded, chunkCRCTable)))
+	payload = append(payload, encoded...)
+
+	return s.writeChunk(chunkTypeData, payload)
+}
+
+func (s *StreamWriter) writeChunk(chunkType byte, payload []byte) error {
+	if len(payload) > maxChunkPayload {
+		return fmt.Errorf("chunk payload of %d bytes exceeds maximum of %d", len(payload), maxChunkPayload)
+	}
+
+	header := [4]byte{chunkType, byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16)}
+	if _, err := s.w.Write(header[:]); err != nil {
+		return fmt.Errorf("failed to write chunk header: %w", err)
+	}
+	if _, err := s.w.Write(payload); err != nil {
+		return fmt.Errorf("failed to write chunk payload: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying writer if it supports closing.
+func (s *StreamWriter) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// StreamReader reads a sequence of TLV values previously written by a
+// StreamWriter from an io.Reader.
+type StreamReader struct {
+	r      io.Reader
+	offset int
+}
+
+// NewStreamReader creates a StreamReader that reads from r.
+func NewStreamReader(r io.Reader) *StreamReader {
+	return &StreamReader{r: r}
+}
+
+// ReadValue reads and decodes the next data chunk, skipping any padding,
+// stream identifier, or unknown skippable chunks along the way. It returns
+// io.EOF once the stream ends cleanly on a chunk boundary.
+func (s *StreamReader) ReadValue() (Data, error) {
+	for {
+		chunkType, payload, err := s.readChunk()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case chunkType == chunkTypeData:
+			return s.decodeDataChunk(payload)
+
+		case chunkType == chunkTypeStreamIdentifier:
+			if string(payload) != streamIdentifierPayload {
+				return nil, fmt.Errorf("bad stream identifier chunk: %q", payload)
+			}
+
+		case chunkType == chunkTypePadding:
+			// Always skippable, payload ignored.
+
+		case chunkType >= 0x80:
+			// Reserved, skippable: an unknown reader discards it and keeps going.
+
+		default:
+			return nil, fmt.Errorf("unknown unskippable chunk type 0x%02x", chunkType)
+		}
+	}
+}
+
+func (s *StreamReader) decodeDataChunk(payload []byte) (Data, error) {
+	chunkOffset := s.offset - len(payload) - 4 // 4-byte chunk header
+
+	if len(payload) < 4 {
+		return nil, fmt.Errorf("data chunk at offset %d too short for a checksum", chunkOffset)
+	}
+	want := binary.LittleEndian.Uint32(payload[:4])
+	body := payload[4:]
+
+	if got := maskChecksum(crc32.Checksum(body, chunkCRCTable)); got != want {
+		return nil, &ChunkCRCError{Offset: chunkOffset, Want: want, Got: got}
+	}
+
+	return Decode(body)
+}
+
+func (s *StreamReader) readChunk() (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		if err == io.EOF {
+			return 0, nil, io.EOF
+		}
+		return 0, nil, fmt.Errorf("failed to read chunk header: %w", err)
+	}
+
+	length := int(header[1]) | int(header[2])<<8 | int(header[3])<<16
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(s.r, payload); err != nil {
+		return 0, nil, fmt.Errorf("failed to read chunk payload: %w", err)
+	}
+
+	s.offset += len(header) + length
+	return header[0], payload, nil
+}
@@ -0,0 +1,102 @@
+package gptcodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestStreamRoundTrip(t *testing.T) {
+	values := []Data{
+		"foo",
+		int32(-42),
+		[]Data{"bar", int32(123)},
+	}
+
+	var buf bytes.Buffer
+	w := NewStreamWriter(&buf)
+	for _, v := range values {
+		if err := w.WriteValue(v); err != nil {
+			t.Fatalf("WriteValue: %v", err)
+		}
+	}
+
+	r := NewStreamReader(&buf)
+	for i, want := range values {
+		got, err := r.ReadValue()
+		if err != nil {
+			t.Fatalf("ReadValue %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("value %d: want %#v, got %#v", i, want, got)
+		}
+	}
+
+	if _, err := r.ReadValue(); err != io.EOF {
+		t.Fatalf("expected io.EOF at end of stream, got %v", err)
+	}
+}
+
+func TestStreamSkipsPaddingAndRepeatedIdentifier(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewStreamWriter(&buf)
+	if err := w.WriteValue("first"); err != nil {
+		t.Fatalf("WriteValue: %v", err)
+	}
+	if err := w.writeChunk(chunkTypePadding, make([]byte, 8)); err != nil {
+		t.Fatalf("writeChunk(padding): %v", err)
+	}
+	if err := w.writeChunk(chunkTypeStreamIdentifier, []byte(streamIdentifierPayload)); err != nil {
+		t.Fatalf("writeChunk(stream identifier): %v", err)
+	}
+	if err := w.WriteValue("second"); err != nil {
+		t.Fatalf("WriteValue: %v", err)
+	}
+
+	r := NewStreamReader(&buf)
+	for _, want := range []Data{"first", "second"} {
+		got, err := r.ReadValue()
+		if err != nil {
+			t.Fatalf("ReadValue: %v", err)
+		}
+		if got != want {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestStreamCRCMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewStreamWriter(&buf)
+	if err := w.WriteValue("hello"); err != nil {
+		t.Fatalf("WriteValue: %v", err)
+	}
+
+	corrupted := buf.Bytes()
+	// Flip a byte inside the data chunk's TLV payload (after the stream
+	// identifier chunk's 4-byte header + 6-byte payload, the data chunk's
+	// 4-byte header, and its 4-byte CRC).
+	dataStart := 4 + len(streamIdentifierPayload) + 4 + 4
+	corrupted[dataStart] ^= 0xff
+
+	r := NewStreamReader(bytes.NewReader(corrupted))
+	_, err := r.ReadValue()
+	if _, ok := err.(*ChunkCRCError); !ok {
+		t.Fatalf("expected *ChunkCRCError, got %T: %v", err, err)
+	}
+}
+
+func TestStreamUnknownUnskippableChunkType(t *testing.T) {
+	var buf bytes.Buffer
+	var header [4]byte
+	header[0] = 0x02 // reserved, unskippable
+	binary.LittleEndian.PutUint16(header[1:3], 0)
+	buf.Write(header[:])
+
+	r := NewStreamReader(&buf)
+	if _, err := r.ReadValue(); err == nil {
+		t.Fatal("expected an error for an unknown unskippable chunk type")
+	}
+}
@@ -0,0 +1,144 @@
+package gptcodec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValueKindRoundTrips(t *testing.T) {
+	cases := []Data{
+		int64(-9223372036854775808),
+		float64(3.14159),
+		true,
+		false,
+		nil,
+		NewMap("a", int32(1), "b", "two", "c", nil),
+		[]Data{NewMap("x", int64(5)), float64(-0.5), nil},
+	}
+	for _, cse := range cases {
+		b, err := Encode(cse)
+		if err != nil {
+			t.Fatalf("Encode(%#v): %v", cse, err)
+		}
+		out, err := Decode(b)
+		if err != nil {
+			t.Fatalf("Decode(%#v): %v", cse, err)
+		}
+		if !reflect.DeepEqual(out, cse) {
+			t.Fatalf("mismatch: want %#v got %#v", cse, out)
+		}
+	}
+}
+
+func TestMapEncodesKeysInSortedOrder(t *testing.T) {
+	m := NewMap("zebra", int32(1), "apple", int32(2), "mango", int32(3))
+
+	b, err := Encode(m)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	// [Tag:1][Count:4] then each (key, value) pair; walk the keys out in
+	// wire order and check they're already sorted.
+	c := &cursor{b: b, off: 1}
+	count, err := c.readU32()
+	if err != nil {
+		t.Fatalf("readU32: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 pairs, got %d", count)
+	}
+
+	var keys []string
+	for i := uint32(0); i < count; i++ {
+		key, off, err := decodeValue(b, c.off)
+		if err != nil {
+			t.Fatalf("decode key %d: %v", i, err)
+		}
+		c.off = off
+		keys = append(keys, key.(string))
+
+		_, off, err = decodeValue(b, c.off)
+		if err != nil {
+			t.Fatalf("decode value %d: %v", i, err)
+		}
+		c.off = off
+	}
+
+	want := []string{"apple", "mango", "zebra"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("keys not sorted: want %v got %v", want, keys)
+	}
+}
+
+func TestDecodeRejectsUnsortedMapKeys(t *testing.T) {
+	var b []byte
+	b = append(b, TagMap)
+	b = writeU32(b, 2)
+	b, _ = encodeValue(b, "zebra")
+	b, _ = encodeValue(b, int32(1))
+	b, _ = encodeValue(b, "apple")
+	b, _ = encodeValue(b, int32(2))
+
+	if _, err := Decode(b); err == nil {
+		t.Fatal("expected an error for unsorted map keys")
+	}
+}
+
+func TestDecodeRejectsDuplicateMapKeys(t *testing.T) {
+	var b []byte
+	b = append(b, TagMap)
+	b = writeU32(b, 2)
+	b, _ = encodeValue(b, "apple")
+	b, _ = encodeValue(b, int32(1))
+	b, _ = encodeValue(b, "apple")
+	b, _ = encodeValue(b, int32(2))
+
+	if _, err := Decode(b); err == nil {
+		t.Fatal("expected an error for duplicate map keys")
+	}
+}
+
+func TestDecodeRejectsNonStringMapKey(t *testing.T) {
+	var b []byte
+	b = append(b, TagMap)
+	b = writeU32(b, 1)
+	b, _ = encodeValue(b, int32(1))
+	b, _ = encodeValue(b, int32(2))
+
+	if _, err := Decode(b); err == nil {
+		t.Fatal("expected an error for a non-string map key")
+	}
+}
+
+func TestNewMapPanicsOnOddArgs(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected NewMap to panic on an odd number of arguments")
+		}
+	}()
+	NewMap("a")
+}
+
+func TestNewMapPanicsOnNonStringKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected NewMap to panic on a non-string key")
+		}
+	}()
+	NewMap(int32(1), "value")
+}
+
+func TestEncodeRejectsOversizedMap(t *testing.T) {
+	m := make(map[string]Data, MaxMapLen+1)
+	for i := 0; i < MaxMapLen+1; i++ {
+		m[randomKeyForTest(i)] = int32(i)
+	}
+	if _, err := Encode(m); err == nil {
+		t.Fatal("expected oversize map error")
+	}
+}
+
+func randomKeyForTest(i int) string {
+	return string(rune('a'+i%26)) + string(rune('a'+(i/26)%26)) + string(rune('a'+(i/676)%26))
+}
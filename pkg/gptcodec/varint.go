@@ -0,0 +1,322 @@
+package gptcodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Value kinds added by gpt_codec.go beyond string/int32/list (int64,
+// float64, bool, null, map) thread through this dialect too: int64 reuses
+// the same self-delimiting varint encoding as int32, bool and null need no
+// length field at all (they're fixed 1 and 0 bytes respectively), and
+// float64 is written as 8 raw big-endian bytes since IEEE-754 bit patterns
+// don't compress under LEB128 the way small integers and lengths do.
+
+// ----------------------------------------------------------------------------
+// Varint wire dialect (v2)
+// ----------------------------------------------------------------------------
+// The default TLV layout uses fixed 4-byte big-endian length fields. This
+// dialect replaces them with uvarint-encoded lengths (and a varint-encoded
+// int32 payload, which is self-delimiting and so carries no length field at
+// all), which shrinks most real payloads since small lengths and values
+// dominate.
+//
+// A v2 buffer starts with the single magic byte dialectMagicVarint, which
+// never collides with a v1 buffer's first byte (always one of the ASCII tag
+// letters such as 'S', 'I', 'L', 'M' or 'Z'). Decode checks for that byte to auto-detect the dialect, so callers
+// that don't care which dialect produced a buffer can keep calling Decode;
+// EncodeWith/DecodeWith exist for callers that want to pick explicitly.
+//
+// Decode rejects non-canonical varints (ones padded with extra continuation
+// bytes to encode the same value less efficiently) rather than silently
+// accepting them, since a decoder that's lenient about encoding shape opens
+// the door to smuggling the same logical value past a byte-level filter in
+// two different wire forms.
+// ----------------------------------------------------------------------------
+
+const dialectMagicVarint byte = 0xC2
+
+// Options selects a wire dialect for EncodeWith/DecodeWith.
+type Options struct {
+	// Varint selects the uvarint-length wire dialect instead of the
+	// default fixed 4-byte length fields.
+	Varint bool
+}
+
+// EncodeWith encodes v using the dialect selected by opts.
+func EncodeWith(v Data, opts Options) ([]byte, error) {
+	if !opts.Varint {
+		return Encode(v)
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, dialectMagicVarint)
+	return encodeValueVarint(buf, v)
+}
+
+// DecodeWith decodes b using the dialect selected by opts.
+func DecodeWith(b []byte, opts Options) (Data, error) {
+	if !opts.Varint {
+		return Decode(b)
+	}
+
+	if len(b) < 1 || b[0] != dialectMagicVarint {
+		return nil, fmt.Errorf("missing varint dialect magic byte 0x%02x", dialectMagicVarint)
+	}
+
+	v, off, err := decodeValueVarint(b, 1)
+	if err != nil {
+		return nil, err
+	}
+	if off != len(b) {
+		return nil, fmt.Errorf("trailing bytes: decoded %d of %d", off, len(b))
+	}
+	return v, nil
+}
+
+func encodeValueVarint(dst []byte, v Data) ([]byte, error) {
+	switch x := v.(type) {
+	case string:
+		bs := []byte(x)
+		if len(bs) > MaxStringLen {
+			return nil, fmt.Errorf("string too long: %d > %d", len(bs), MaxStringLen)
+		}
+		dst = append(dst, TagString)
+		dst = binary.AppendUvarint(dst, uint64(len(bs)))
+		return append(dst, bs...), nil
+
+	case int32:
+		dst = append(dst, TagInt32)
+		return binary.AppendVarint(dst, int64(x)), nil
+
+	case int64:
+		dst = append(dst, TagInt64)
+		return binary.AppendVarint(dst, x), nil
+
+	case float64:
+		dst = append(dst, TagFloat64)
+		return writeU64(dst, math.Float64bits(x)), nil
+
+	case bool:
+		dst = append(dst, TagBool)
+		if x {
+			return append(dst, 0x01), nil
+		}
+		return append(dst, 0x00), nil
+
+	case nil:
+		return append(dst, TagNull), nil
+
+	case []Data:
+		if len(x) > MaxListLen {
+			return nil, fmt.Errorf("list too long: %d > %d", len(x), MaxListLen)
+		}
+		dst = append(dst, TagList)
+		dst = binary.AppendUvarint(dst, uint64(len(x)))
+		for _, elem := range x {
+			var err error
+			dst, err = encodeValueVarint(dst, elem)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+
+	case map[string]Data:
+		if len(x) > MaxMapLen {
+			return nil, fmt.Errorf("map too long: %d > %d", len(x), MaxMapLen)
+		}
+		dst = append(dst, TagMap)
+		dst = binary.AppendUvarint(dst, uint64(len(x)))
+		for _, key := range sortedKeys(x) {
+			var err error
+			dst, err = encodeValueVarint(dst, key)
+			if err != nil {
+				return nil, err
+			}
+			dst, err = encodeValueVarint(dst, x[key])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type %T (allowed: string | int32 | int64 | float64 | bool | nil | []Data | map[string]Data)", v)
+	}
+}
+
+// readCanonicalUvarint decodes a uvarint from the front of b, rejecting any
+// encoding longer than the minimal one for the decoded value.
+func readCanonicalUvarint(b []byte) (uint64, int, error) {
+	v, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, 0, errors.New("invalid uvarint field")
+	}
+	var canon [binary.MaxVarintLen64]byte
+	if binary.PutUvarint(canon[:], v) != n {
+		return 0, 0, errors.New("non-canonical uvarint encoding")
+	}
+	return v, n, nil
+}
+
+// readCanonicalVarint decodes a varint from the front of b, rejecting any
+// encoding longer than the minimal one for the decoded value.
+func readCanonicalVarint(b []byte) (int64, int, error) {
+	v, n := binary.Varint(b)
+	if n <= 0 {
+		return 0, 0, errors.New("invalid varint field")
+	}
+	var canon [binary.MaxVarintLen64]byte
+	if binary.PutVarint(canon[:], v) != n {
+		return 0, 0, errors.New("non-canonical varint encoding")
+	}
+	return v, n, nil
+}
+
+func (c *cursor) readUvarintCanonical() (uint64, error) {
+	v, n, err := readCanonicalUvarint(c.b[c.off:])
+	if err != nil {
+		return 0, err
+	}
+	c.off += n
+	return v, nil
+}
+
+func (c *cursor) readVarintCanonical() (int64, error) {
+	v, n, err := readCanonicalVarint(c.b[c.off:])
+	if err != nil {
+		return 0, err
+	}
+	c.off += n
+	return v, nil
+}
+
+func decodeValueVarint(b []byte, start int) (Data, int, error) {
+	c := &cursor{b: b, off: start}
+	tag, err := c.readByte()
+	if err != nil {
+		return nil, start, err
+	}
+
+	switch tag {
+	case TagString:
+		ln, err := c.readUvarintCanonical()
+		if err != nil {
+			return nil, start, err
+		}
+		if ln > MaxStringLen {
+			return nil, start, fmt.Errorf("string too long: %d > %d", ln, MaxStringLen)
+		}
+		payload, err := c.readN(int(ln))
+		if err != nil {
+			return nil, start, err
+		}
+		return string(payload), c.off, nil
+
+	case TagInt32:
+		v, err := c.readVarintCanonical()
+		if err != nil {
+			return nil, start, err
+		}
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, start, fmt.Errorf("int32 value %d out of range", v)
+		}
+		return int32(v), c.off, nil
+
+	case TagInt64:
+		v, err := c.readVarintCanonical()
+		if err != nil {
+			return nil, start, err
+		}
+		return v, c.off, nil
+
+	case TagFloat64:
+		u, err := c.readU64()
+		if err != nil {
+			return nil, start, err
+		}
+		return math.Float64frombits(u), c.off, nil
+
+	case TagBool:
+		payload, err := c.readN(1)
+		if err != nil {
+			return nil, start, err
+		}
+		switch payload[0] {
+		case 0x00:
+			return false, c.off, nil
+		case 0x01:
+			return true, c.off, nil
+		default:
+			return nil, start, fmt.Errorf("bool payload must be 0x00 or 0x01, got 0x%02x", payload[0])
+		}
+
+	case TagNull:
+		return nil, c.off, nil
+
+	case TagList:
+		count, err := c.readUvarintCanonical()
+		if err != nil {
+			return nil, start, err
+		}
+		if count > MaxListLen {
+			return nil, start, fmt.Errorf("list too long: %d > %d", count, MaxListLen)
+		}
+		res := make([]Data, 0, count)
+		for i := uint64(0); i < count; i++ {
+			v, off, err := decodeValueVarint(b, c.off)
+			if err != nil {
+				return nil, start, err
+			}
+			c.off = off
+			res = append(res, v)
+		}
+		return res, c.off, nil
+
+	case TagMap:
+		count, err := c.readUvarintCanonical()
+		if err != nil {
+			return nil, start, err
+		}
+		if count > MaxMapLen {
+			return nil, start, fmt.Errorf("map too long: %d > %d", count, MaxMapLen)
+		}
+		res := make(map[string]Data, count)
+		lastKey := ""
+		haveLastKey := false
+		for i := uint64(0); i < count; i++ {
+			keyVal, off, err := decodeValueVarint(b, c.off)
+			if err != nil {
+				return nil, start, err
+			}
+			c.off = off
+			key, ok := keyVal.(string)
+			if !ok {
+				return nil, start, fmt.Errorf("map key %d must be a string, got %T", i, keyVal)
+			}
+			if haveLastKey {
+				if key == lastKey {
+					return nil, start, fmt.Errorf("map has duplicate key %q", key)
+				}
+				if key < lastKey {
+					return nil, start, fmt.Errorf("map keys are not in sorted order: %q before %q", lastKey, key)
+				}
+			}
+			lastKey, haveLastKey = key, true
+
+			val, off, err := decodeValueVarint(b, c.off)
+			if err != nil {
+				return nil, start, err
+			}
+			c.off = off
+			res[key] = val
+		}
+		return res, c.off, nil
+
+	default:
+		return nil, start, fmt.Errorf("unknown tag 0x%X at offset %d", tag, start)
+	}
+}
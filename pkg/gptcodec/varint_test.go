@@ -0,0 +1,101 @@
+package gptcodec
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestVarintRoundTrips(t *testing.T) {
+	cases := []Data{
+		"foo",
+		int32(-42),
+		[]Data{"bar", int32(123)},
+		[]Data{"αβγ", []Data{"nested", []Data{"deep"}}},
+	}
+	for _, cse := range cases {
+		b, err := EncodeWith(cse, Options{Varint: true})
+		if err != nil {
+			t.Fatalf("EncodeWith error: %v", err)
+		}
+		if b[0] != dialectMagicVarint {
+			t.Fatalf("expected leading magic byte 0x%02x, got 0x%02x", dialectMagicVarint, b[0])
+		}
+
+		out, err := DecodeWith(b, Options{Varint: true})
+		if err != nil {
+			t.Fatalf("DecodeWith error: %v", err)
+		}
+		if !reflect.DeepEqual(out, cse) {
+			t.Fatalf("mismatch: want %#v got %#v", cse, out)
+		}
+	}
+}
+
+func TestDecodeAutoDetectsVarintDialect(t *testing.T) {
+	v := []Data{"hello", int32(7)}
+
+	b, err := EncodeWith(v, Options{Varint: true})
+	if err != nil {
+		t.Fatalf("EncodeWith error: %v", err)
+	}
+
+	out, err := Decode(b)
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !reflect.DeepEqual(out, v) {
+		t.Fatalf("mismatch: want %#v got %#v", v, out)
+	}
+}
+
+func TestVarintSmallerThanFixedForSmallValues(t *testing.T) {
+	v := "x"
+
+	fixed, err := Encode(v)
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	varint, err := EncodeWith(v, Options{Varint: true})
+	if err != nil {
+		t.Fatalf("EncodeWith error: %v", err)
+	}
+
+	if len(varint) >= len(fixed) {
+		t.Fatalf("expected varint dialect to be smaller: fixed=%d varint=%d", len(fixed), len(varint))
+	}
+}
+
+func TestVarintConstraints(t *testing.T) {
+	bigStr := make([]byte, MaxStringLen+1)
+	if _, err := EncodeWith(string(bigStr), Options{Varint: true}); err == nil {
+		t.Fatal("expected oversize string error")
+	}
+
+	bigList := make([]Data, MaxListLen+1)
+	if _, err := EncodeWith(bigList, Options{Varint: true}); err == nil {
+		t.Fatal("expected oversize list error")
+	}
+}
+
+func TestVarintRejectsNonCanonicalLength(t *testing.T) {
+	b := []byte{dialectMagicVarint, TagString}
+	// A 1-byte-value uvarint padded out to 2 bytes with a continuation bit:
+	// encodes 1 but isn't the minimal (1-byte) form.
+	b = append(b, 0x81, 0x00)
+	b = append(b, 'x')
+
+	if _, err := DecodeWith(b, Options{Varint: true}); err == nil {
+		t.Fatal("expected non-canonical uvarint to be rejected")
+	}
+}
+
+func TestVarintRejectsMissingMagicByte(t *testing.T) {
+	b := []byte{TagString}
+	b = binary.AppendUvarint(b, 1)
+	b = append(b, 'x')
+
+	if _, err := DecodeWith(b, Options{Varint: true}); err == nil {
+		t.Fatal("expected missing magic byte to be rejected")
+	}
+}
@@ -1,8 +1,10 @@
 package zerocopycodec
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"unsafe"
 )
 
@@ -13,11 +15,33 @@ type Data []interface{}
 type ValueType byte
 
 const (
-	TypeInt32  ValueType = 0x01
-	TypeString ValueType = 0x02
-	TypeArray  ValueType = 0x03
+	TypeInt32   ValueType = 0x01
+	TypeString  ValueType = 0x02
+	TypeArray   ValueType = 0x03
+	TypeInt64   ValueType = 0x04
+	TypeFloat64 ValueType = 0x05
+	TypeBool    ValueType = 0x06
+	TypeNull    ValueType = 0x07
+	TypeBytes   ValueType = 0x08
+	TypeMap     ValueType = 0x09
+
+	// TypeStringRef and TypeSymbolTable implement optional string
+	// interning; see intern.go.
+	TypeStringRef   ValueType = 0x0A
+	TypeSymbolTable ValueType = 0x0B
 )
 
+// UnknownTypeError is returned when a decode encounters a type byte it does
+// not recognize, so forward-compatibility layers can tell "malformed data"
+// apart from "data from a newer format version" and choose to skip it.
+type UnknownTypeError struct {
+	TypeByte ValueType
+}
+
+func (e *UnknownTypeError) Error() string {
+	return fmt.Sprintf("unknown value type: %d", e.TypeByte)
+}
+
 // Header layout for our format:
 // [4 bytes: total length][remaining bytes: data]
 //
@@ -27,6 +51,16 @@ const (
 // Encoder provides efficient serialization with minimal allocations
 type Encoder struct {
 	buf []byte
+
+	// Intern enables the symbol table described in intern.go: strings
+	// that repeat often enough to be worth deduplicating are written
+	// once into a table at the front of the buffer and referenced by
+	// index everywhere else.
+	Intern bool
+
+	// internIndex maps a string to its table index during an Encode call
+	// with Intern set; nil otherwise. Rebuilt on every Encode.
+	internIndex map[string]int
 }
 
 // NewEncoder creates a new encoder with initial capacity
@@ -45,6 +79,15 @@ func (e *Encoder) Encode(data Data) []byte {
 	// Reserve 4 bytes for total length header
 	e.buf = append(e.buf, 0, 0, 0, 0)
 
+	e.internIndex = nil
+	if e.Intern {
+		table, index := buildInternTable(data)
+		if len(table) > 0 {
+			e.encodeSymbolTable(table)
+			e.internIndex = index
+		}
+	}
+
 	e.encodeValue(data)
 
 	// Write total length at the beginning
@@ -61,6 +104,11 @@ func (e *Encoder) encodeValue(value interface{}) {
 		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(v))
 
 	case string:
+		if idx, ok := e.internIndex[v]; ok {
+			e.buf = append(e.buf, byte(TypeStringRef))
+			e.buf = binary.AppendUvarint(e.buf, uint64(idx))
+			return
+		}
 		e.buf = append(e.buf, byte(TypeString))
 		strBytes := []byte(v)
 		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(strBytes)))
@@ -80,6 +128,38 @@ func (e *Encoder) encodeValue(value interface{}) {
 		for _, item := range v {
 			e.encodeValue(item)
 		}
+
+	case int64:
+		e.buf = append(e.buf, byte(TypeInt64))
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(v))
+
+	case float64:
+		e.buf = append(e.buf, byte(TypeFloat64))
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+
+	case bool:
+		e.buf = append(e.buf, byte(TypeBool))
+		if v {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+
+	case nil:
+		e.buf = append(e.buf, byte(TypeNull))
+
+	case []byte:
+		e.buf = append(e.buf, byte(TypeBytes))
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(v)))
+		e.buf = append(e.buf, v...)
+
+	case map[string]interface{}:
+		e.buf = append(e.buf, byte(TypeMap))
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(v)))
+		for key, val := range v {
+			e.encodeValue(key)
+			e.encodeValue(val)
+		}
 	}
 }
 
@@ -87,6 +167,11 @@ func (e *Encoder) encodeValue(value interface{}) {
 type Decoder struct {
 	data   []byte
 	offset int
+
+	// table resolves TypeStringRef entries against the symbol table read
+	// from the front of the buffer, if any (see intern.go). Entries
+	// alias data, same as TypeString, so interning never copies.
+	table []string
 }
 
 // NewDecoder creates a decoder that works directly with the provided byte slice
@@ -114,6 +199,10 @@ func (d *Decoder) Decode() (Data, error) {
 
 	d.offset = 4 // Skip the length header
 
+	if err := d.maybeDecodeSymbolTable(); err != nil {
+		return nil, err
+	}
+
 	value, err := d.decodeValue()
 	if err != nil {
 		return nil, err
@@ -182,8 +271,90 @@ func (d *Decoder) decodeValue() (interface{}, error) {
 
 		return result, nil
 
+	case TypeInt64:
+		if d.offset+8 > len(d.data) {
+			return nil, fmt.Errorf("insufficient data for int64")
+		}
+		value := int64(binary.LittleEndian.Uint64(d.data[d.offset:]))
+		d.offset += 8
+		return value, nil
+
+	case TypeFloat64:
+		if d.offset+8 > len(d.data) {
+			return nil, fmt.Errorf("insufficient data for float64")
+		}
+		bits := binary.LittleEndian.Uint64(d.data[d.offset:])
+		d.offset += 8
+		return math.Float64frombits(bits), nil
+
+	case TypeBool:
+		if d.offset+1 > len(d.data) {
+			return nil, fmt.Errorf("insufficient data for bool")
+		}
+		value := d.data[d.offset] != 0
+		d.offset++
+		return value, nil
+
+	case TypeNull:
+		return nil, nil
+
+	case TypeBytes:
+		if d.offset+4 > len(d.data) {
+			return nil, fmt.Errorf("insufficient data for bytes length")
+		}
+		byteLen := binary.LittleEndian.Uint32(d.data[d.offset:])
+		d.offset += 4
+
+		if d.offset+int(byteLen) > len(d.data) {
+			return nil, fmt.Errorf("insufficient data for bytes content")
+		}
+
+		// ZERO-COPY: alias the source buffer, same as TypeString
+		raw := d.data[d.offset : d.offset+int(byteLen)]
+		d.offset += int(byteLen)
+
+		return raw, nil
+
+	case TypeMap:
+		if d.offset+4 > len(d.data) {
+			return nil, fmt.Errorf("insufficient data for map length")
+		}
+		mapLen := binary.LittleEndian.Uint32(d.data[d.offset:])
+		d.offset += 4
+
+		result := make(map[string]interface{}, mapLen)
+		for i := uint32(0); i < mapLen; i++ {
+			keyValue, err := d.decodeValue()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode map key %d: %w", i, err)
+			}
+			key, ok := keyValue.(string)
+			if !ok {
+				return nil, fmt.Errorf("map key %d must be a string", i)
+			}
+
+			value, err := d.decodeValue()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode map value %d: %w", i, err)
+			}
+			result[key] = value
+		}
+
+		return result, nil
+
+	case TypeStringRef:
+		idx, n := binary.Uvarint(d.data[d.offset:])
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid string ref index")
+		}
+		d.offset += n
+		if int(idx) >= len(d.table) {
+			return nil, fmt.Errorf("string ref index %d out of range (table has %d entries)", idx, len(d.table))
+		}
+		return d.table[idx], nil
+
 	default:
-		return nil, fmt.Errorf("unknown value type: %d", valueType)
+		return nil, &UnknownTypeError{TypeByte: valueType}
 	}
 }
 
@@ -193,9 +364,131 @@ func Encode(data Data) []byte {
 	return encoder.Encode(data)
 }
 
-func Decode(encoded []byte) (Data, error) {
-	decoder := NewDecoder(encoded)
-	return decoder.Decode()
+// AppendEncode serializes data and appends the result to buf, returning the
+// extended slice. This mirrors the Append/Encode/Decode pattern Go 1.23 added
+// to encoding/binary: callers can pass in a pre-sized or pooled buffer to
+// pack several messages into one allocation-free buffer.
+func AppendEncode(buf []byte, data Data) ([]byte, error) {
+	start := len(buf)
+	buf = append(buf, 0, 0, 0, 0)
+
+	var err error
+	buf, err = appendValue(buf, data)
+	if err != nil {
+		return nil, err
+	}
+
+	totalLen := len(buf) - start - 4
+	binary.LittleEndian.PutUint32(buf[start:start+4], uint32(totalLen))
+	return buf, nil
+}
+
+// appendValue recursively encodes a single value by appending to dst
+func appendValue(dst []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case int32:
+		dst = append(dst, byte(TypeInt32))
+		return binary.LittleEndian.AppendUint32(dst, uint32(v)), nil
+
+	case string:
+		dst = append(dst, byte(TypeString))
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(v)))
+		return append(dst, v...), nil
+
+	case Data:
+		dst = append(dst, byte(TypeArray))
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(v)))
+		for _, item := range v {
+			var err error
+			dst, err = appendValue(dst, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+
+	case []interface{}:
+		return appendValue(dst, Data(v))
+
+	case int64:
+		dst = append(dst, byte(TypeInt64))
+		return binary.LittleEndian.AppendUint64(dst, uint64(v)), nil
+
+	case float64:
+		dst = append(dst, byte(TypeFloat64))
+		return binary.LittleEndian.AppendUint64(dst, math.Float64bits(v)), nil
+
+	case bool:
+		dst = append(dst, byte(TypeBool))
+		if v {
+			return append(dst, 1), nil
+		}
+		return append(dst, 0), nil
+
+	case nil:
+		return append(dst, byte(TypeNull)), nil
+
+	case []byte:
+		dst = append(dst, byte(TypeBytes))
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(v)))
+		return append(dst, v...), nil
+
+	case map[string]interface{}:
+		dst = append(dst, byte(TypeMap))
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(v)))
+		for key, val := range v {
+			var err error
+			dst, err = appendValue(dst, key)
+			if err != nil {
+				return nil, err
+			}
+			dst, err = appendValue(dst, val)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type: %T", v)
+	}
+}
+
+// decodePrefix decodes a single value from the front of buf, returning the
+// number of bytes consumed so callers can decode subsequent messages packed
+// into the same buffer.
+func decodePrefix(buf []byte) (Data, int, error) {
+	if len(buf) < 4 {
+		return nil, 0, fmt.Errorf("invalid data: too short")
+	}
+
+	totalLen := binary.LittleEndian.Uint32(buf[0:4])
+	end := 4 + int(totalLen)
+	if end > len(buf) {
+		return nil, 0, fmt.Errorf("invalid data: length mismatch")
+	}
+
+	decoder := &Decoder{data: buf[:end], offset: 4}
+	if err := decoder.maybeDecodeSymbolTable(); err != nil {
+		return nil, 0, err
+	}
+	value, err := decoder.decodeValue()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	arr, ok := value.(Data)
+	if !ok {
+		return nil, 0, fmt.Errorf("root element must be an array")
+	}
+
+	return arr, end, nil
+}
+
+// Decode deserializes a value from the front of encoded, returning the
+// decoded Data along with the number of bytes consumed.
+func Decode(encoded []byte) (Data, int, error) {
+	return decodePrefix(encoded)
 }
 
 // Performance-optimized pool for reusing encoders
@@ -255,6 +548,46 @@ func valueEqual(a, b interface{}) bool {
 		}
 		return false
 
+	case int64:
+		if vb, ok := b.(int64); ok {
+			return va == vb
+		}
+		return false
+
+	case float64:
+		if vb, ok := b.(float64); ok {
+			return va == vb
+		}
+		return false
+
+	case bool:
+		if vb, ok := b.(bool); ok {
+			return va == vb
+		}
+		return false
+
+	case nil:
+		return b == nil
+
+	case []byte:
+		if vb, ok := b.([]byte); ok {
+			return bytes.Equal(va, vb)
+		}
+		return false
+
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for key, val := range va {
+			other, ok := vb[key]
+			if !ok || !valueEqual(val, other) {
+				return false
+			}
+		}
+		return true
+
 	default:
 		return false
 	}
@@ -20,7 +20,7 @@ func TestMaxListLength(t *testing.T) {
 	}
 
 	encoded := Encode(maxData)
-	decoded, err := Decode(encoded)
+	decoded, _, err := Decode(encoded)
 	if err != nil {
 		t.Fatalf("Failed to encode/decode max length array: %v", err)
 	}
@@ -44,7 +44,7 @@ func TestMaxStringLength(t *testing.T) {
 	testData := Data{maxString, int32(42)}
 
 	encoded := Encode(testData)
-	decoded, err := Decode(encoded)
+	decoded, _, err := Decode(encoded)
 	if err != nil {
 		t.Fatalf("Failed to encode/decode max length string: %v", err)
 	}
@@ -84,7 +84,7 @@ func TestNestedMaxConstraints(t *testing.T) {
 	}
 
 	encoded := Encode(testData)
-	decoded, err := Decode(encoded)
+	decoded, _, err := Decode(encoded)
 	if err != nil {
 		t.Fatalf("Failed to encode/decode nested max constraints: %v", err)
 	}
@@ -100,7 +100,7 @@ func TestZeroCopyStrings(t *testing.T) {
 	testData := Data{testStr}
 
 	encoded := Encode(testData)
-	decoded, err := Decode(encoded)
+	decoded, _, err := Decode(encoded)
 	if err != nil {
 		t.Fatalf("Failed to decode: %v", err)
 	}
@@ -136,7 +136,7 @@ func TestUTF8Support(t *testing.T) {
 	}
 
 	encoded := Encode(testData)
-	decoded, err := Decode(encoded)
+	decoded, _, err := Decode(encoded)
 	if err != nil {
 		t.Fatalf("Failed to encode/decode UTF-8 strings: %v", err)
 	}
@@ -149,20 +149,20 @@ func TestUTF8Support(t *testing.T) {
 // TestErrorCases verifies proper error handling
 func TestErrorCases(t *testing.T) {
 	// Test empty data
-	_, err := Decode([]byte{})
+	_, _, err := Decode([]byte{})
 	if err == nil {
 		t.Error("Expected error for empty data")
 	}
 
 	// Test truncated data
-	_, err = Decode([]byte{1, 2})
+	_, _, err = Decode([]byte{1, 2})
 	if err == nil {
 		t.Error("Expected error for truncated data")
 	}
 
 	// Test invalid type
 	invalidData := []byte{0, 0, 0, 2, 0xFF, 0, 0, 0, 0}
-	_, err = Decode(invalidData)
+	_, _, err = Decode(invalidData)
 	if err == nil {
 		t.Error("Expected error for invalid type")
 	}
@@ -0,0 +1,125 @@
+package zerocopycodec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"unsafe"
+)
+
+// ----------------------------------------------------------------------------
+// Optional string interning
+// ----------------------------------------------------------------------------
+// Mirrors claudecodec's symbol table (see that package's intern.go): a
+// TypeSymbolTable value at the front of the buffer holds every string
+// worth deduplicating, and later occurrences are written as TypeStringRef
+// plus a uvarint index instead of a full TypeString. Decode aliases each
+// table entry directly out of the source buffer, the same way it aliases
+// a plain TypeString, so interning never costs zero-copy decode.
+// ----------------------------------------------------------------------------
+
+const maxSymbolTableLen = 1000
+
+// buildInternTable scans data for strings worth interning: each must repeat
+// at least twice and only earns its spot once referencing it repeatedly
+// costs less than writing it out in full each time. It returns the
+// qualifying strings in a deterministic order (so repeated encodes of the
+// same Data produce identical output) along with a map from string to
+// table index.
+func buildInternTable(data Data) (table []string, index map[string]int) {
+	counts := make(map[string]int)
+	countStrings(data, counts)
+
+	for s, count := range counts {
+		overhead := len(s) + 2
+		if count >= 2 && len(s)*count > overhead {
+			table = append(table, s)
+		}
+	}
+	sort.Strings(table)
+
+	index = make(map[string]int, len(table))
+	for i, s := range table {
+		index[s] = i
+	}
+	return table, index
+}
+
+// countStrings walks value, tallying every string it finds (including map
+// keys) into counts.
+func countStrings(value interface{}, counts map[string]int) {
+	switch v := value.(type) {
+	case string:
+		counts[v]++
+
+	case Data:
+		for _, item := range v {
+			countStrings(item, counts)
+		}
+
+	case []interface{}:
+		countStrings(Data(v), counts)
+
+	case map[string]interface{}:
+		for key, val := range v {
+			counts[key]++
+			countStrings(val, counts)
+		}
+	}
+}
+
+// encodeSymbolTable writes table to e.buf as a TypeSymbolTable value.
+// Callers should only call this when table is non-empty.
+func (e *Encoder) encodeSymbolTable(table []string) {
+	e.buf = append(e.buf, byte(TypeSymbolTable))
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(table)))
+	for _, s := range table {
+		e.buf = append(e.buf, byte(TypeString))
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+		e.buf = append(e.buf, s...)
+	}
+}
+
+// maybeDecodeSymbolTable reads a TypeSymbolTable off the front of d.data at
+// d.offset, if present, and populates d.table. Buffers with no symbol
+// table (the common case) leave d.offset untouched.
+func (d *Decoder) maybeDecodeSymbolTable() error {
+	if d.offset >= len(d.data) || ValueType(d.data[d.offset]) != TypeSymbolTable {
+		return nil
+	}
+	d.offset++
+
+	count, n := binary.Uvarint(d.data[d.offset:])
+	if n <= 0 {
+		return fmt.Errorf("invalid symbol table count")
+	}
+	d.offset += n
+	if count > maxSymbolTableLen {
+		return fmt.Errorf("symbol table count %d exceeds maximum of %d", count, maxSymbolTableLen)
+	}
+
+	table := make([]string, count)
+	for i := uint64(0); i < count; i++ {
+		if d.offset >= len(d.data) || ValueType(d.data[d.offset]) != TypeString {
+			return fmt.Errorf("malformed symbol table entry %d", i)
+		}
+		d.offset++
+
+		length, n := binary.Uvarint(d.data[d.offset:])
+		if n <= 0 {
+			return fmt.Errorf("invalid symbol %d length", i)
+		}
+		d.offset += n
+		if d.offset+int(length) > len(d.data) {
+			return fmt.Errorf("insufficient data for symbol %d", i)
+		}
+
+		// ZERO-COPY: alias the source buffer, same as TypeString.
+		raw := d.data[d.offset : d.offset+int(length)]
+		table[i] = *(*string)(unsafe.Pointer(&raw))
+		d.offset += int(length)
+	}
+
+	d.table = table
+	return nil
+}
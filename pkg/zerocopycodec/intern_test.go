@@ -0,0 +1,40 @@
+package zerocopycodec
+
+import "testing"
+
+func TestInternDeduplicatesRepeatedStrings(t *testing.T) {
+	data := Data{
+		map[string]interface{}{"name": "alice", "role": "admin"},
+		map[string]interface{}{"name": "bob", "role": "admin"},
+		map[string]interface{}{"name": "carol", "role": "admin"},
+	}
+
+	enc := &Encoder{Intern: true}
+	encoded := enc.Encode(data)
+	plain := Encode(data)
+
+	if len(encoded) >= len(plain) {
+		t.Errorf("expected interned encoding (%d bytes) to be smaller than uncompressed (%d bytes)", len(encoded), len(plain))
+	}
+
+	decoded, _, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !DeepEqual(data, decoded) {
+		t.Errorf("interned data did not round-trip: want %v, got %v", data, decoded)
+	}
+}
+
+func TestInternBackwardCompatibleWithPlainBuffers(t *testing.T) {
+	data := Data{"short", int32(1)}
+
+	encoded := Encode(data)
+	decoded, _, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode of non-interned buffer failed: %v", err)
+	}
+	if !DeepEqual(data, decoded) {
+		t.Errorf("plain buffer without a symbol table did not round-trip: want %v, got %v", data, decoded)
+	}
+}
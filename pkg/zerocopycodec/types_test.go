@@ -0,0 +1,34 @@
+package zerocopycodec
+
+import "testing"
+
+func TestExtendedTypesRoundTrip(t *testing.T) {
+	testData := Data{
+		int64(-9223372036854775808),
+		3.14159265358979,
+		true,
+		false,
+		nil,
+		[]byte{0x00, 0x01, 0xFF},
+		map[string]interface{}{"a": int32(1), "b": "two"},
+	}
+
+	encoded := Encode(testData)
+	decoded, _, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if !DeepEqual(testData, decoded) {
+		t.Errorf("extended types did not round-trip: want %v, got %v", testData, decoded)
+	}
+}
+
+func TestUnknownValueTypeError(t *testing.T) {
+	// Valid 4-byte length header followed by a type byte nothing decodes.
+	invalid := []byte{1, 0, 0, 0, 0xEE}
+	_, _, err := Decode(invalid)
+	if _, ok := err.(*UnknownTypeError); !ok {
+		t.Fatalf("expected *UnknownTypeError, got %T: %v", err, err)
+	}
+}
@@ -30,7 +30,7 @@ func BenchmarkSmallData(b *testing.B) {
 	b.Run("Decode", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			decoded, err := Decode(encoded)
+			decoded, _, err := Decode(encoded)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -43,7 +43,7 @@ func BenchmarkSmallData(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			encoded := encoder.Encode(smallData)
-			decoded, err := Decode(encoded)
+			decoded, _, err := Decode(encoded)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -80,7 +80,7 @@ func BenchmarkMediumData(b *testing.B) {
 	b.Run("Decode", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			decoded, err := Decode(encoded)
+			decoded, _, err := Decode(encoded)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -93,7 +93,7 @@ func BenchmarkMediumData(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			encoded := encoder.Encode(mediumData)
-			decoded, err := Decode(encoded)
+			decoded, _, err := Decode(encoded)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -140,7 +140,7 @@ func BenchmarkLargeData(b *testing.B) {
 	b.Run("Decode", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			decoded, err := Decode(encoded)
+			decoded, _, err := Decode(encoded)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -153,7 +153,7 @@ func BenchmarkLargeData(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			encoded := encoder.Encode(largeData)
-			decoded, err := Decode(encoded)
+			decoded, _, err := Decode(encoded)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -200,7 +200,7 @@ func BenchmarkMemoryComparison(b *testing.B) {
 		b.ResetTimer()
 		b.ReportAllocs()
 		for i := 0; i < b.N; i++ {
-			decoded, err := Decode(encoded)
+			decoded, _, err := Decode(encoded)
 			if err != nil {
 				b.Fatal(err)
 			}